@@ -0,0 +1,284 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"ualabackend/entities/audit"
+	"ualabackend/entities/moderation"
+	"ualabackend/entities/user"
+	adminRepo "ualabackend/repositories/admin"
+	auditRepo "ualabackend/repositories/audit"
+	moderationRepo "ualabackend/repositories/moderation"
+	tweetRepo "ualabackend/repositories/tweet"
+	userRepo "ualabackend/repositories/user"
+
+	"github.com/gin-gonic/gin"
+)
+
+func adminRoutes(router *gin.Engine, repo *adminRepo.Repository, users *userRepo.Repository, tweets *tweetRepo.Repository, moderations *moderationRepo.Repository, audits *auditRepo.Repository) {
+	a := router.Group("/admin", requireRole(users, user.RoleAdmin))
+	{
+		a.GET("/users", func(c *gin.Context) { getAccounts(c, users) })
+		a.POST("/users/:id/suspend", func(c *gin.Context) { suspendAccount(c, moderations, tweets, users, audits) })
+		a.POST("/users/:id/unsuspend", func(c *gin.Context) { unsuspendAccount(c, moderations, tweets, users, audits) })
+		a.PUT("/users/:id/role", func(c *gin.Context) { setRole(c, users, audits) })
+		a.DELETE("/tweets/:id", func(c *gin.Context) { forceDeleteTweet(c, tweets, audits) })
+		a.GET("/stats", func(c *gin.Context) { getStats(c, repo) })
+		a.POST("/timelines/rebuild", func(c *gin.Context) { rebuildTimelines(c, users, audits) })
+		a.GET("/audit", func(c *gin.Context) { getAuditLog(c, audits) })
+	}
+}
+
+// getAccounts godoc
+// @Summary Listar cuentas
+// @Description Devuelve las cuentas con su rol y su estado de moderación, ordenadas por ID. Solo para administradores
+// @Tags administración
+// @Produce json
+// @Param Authorization header string true "Token Bearer del administrador"
+// @Param status query string false "Estado: all (por defecto), active, suspended o deactivated"
+// @Param limit query int false "Cantidad máxima de resultados"
+// @Param offset query int false "Desplazamiento para paginar"
+// @Success 200 {array} user.Account
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/users [get]
+func getAccounts(c *gin.Context, users *userRepo.Repository) {
+	status := c.DefaultQuery("status", userRepo.AccountsAll)
+	if !userRepo.ValidAccountFilter(status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Estado inválido"})
+		return
+	}
+	limit, offset, ok := pagination(c)
+	if !ok {
+		return
+	}
+
+	accounts, err := users.Accounts(status, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron obtener las cuentas"})
+		return
+	}
+	c.JSON(http.StatusOK, accounts)
+}
+
+// suspendAccount godoc
+// @Summary Suspender una cuenta
+// @Description Suspende la cuenta, ocultándola junto con sus tweets. Queda registrado en el historial de moderación. Solo para administradores
+// @Tags administración
+// @Produce json
+// @Param id path int true "ID del usuario"
+// @Param Authorization header string true "Token Bearer del administrador"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/users/{id}/suspend [post]
+func suspendAccount(c *gin.Context, moderations *moderationRepo.Repository, tweets *tweetRepo.Repository, users *userRepo.Repository, audits *auditRepo.Repository) {
+	setSuspension(c, moderations, tweets, users, audits, moderation.ActionSuspendUser)
+}
+
+// unsuspendAccount godoc
+// @Summary Levantar la suspensión de una cuenta
+// @Description Vuelve a mostrar la cuenta suspendida y sus tweets. Queda registrado en el historial de moderación. Solo para administradores
+// @Tags administración
+// @Produce json
+// @Param id path int true "ID del usuario"
+// @Param Authorization header string true "Token Bearer del administrador"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/users/{id}/unsuspend [post]
+func unsuspendAccount(c *gin.Context, moderations *moderationRepo.Repository, tweets *tweetRepo.Repository, users *userRepo.Repository, audits *auditRepo.Repository) {
+	setSuspension(c, moderations, tweets, users, audits, moderation.ActionUnsuspendUser)
+}
+
+// setSuspension applies action, either suspend_user or unsuspend_user, to
+// the user in the path on behalf of the administrator.
+func setSuspension(c *gin.Context, moderations *moderationRepo.Repository, tweets *tweetRepo.Repository, users *userRepo.Repository, audits *auditRepo.Repository, action string) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	adminID := viewerID(c)
+	if id == adminID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No puede aplicarse a su propia cuenta"})
+		return
+	}
+
+	u, err := users.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al buscar el usuario"})
+		return
+	}
+	if u == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+		return
+	}
+
+	err = moderations.Act(adminID, moderation.ActionInput{
+		Action:     action,
+		TargetType: moderation.TargetUser,
+		TargetID:   id,
+	})
+	if !moderationError(c, err) {
+		return
+	}
+	syncEnforcement(action, id, tweets, users)
+
+	suspended := action == moderation.ActionSuspendUser
+	auditAction, message := audit.ActionUserSuspend, "Cuenta suspendida"
+	if !suspended {
+		auditAction, message = audit.ActionUserUnsuspend, "Suspensión levantada"
+	}
+	recordAudit(c, audits, auditAction, audit.TargetUser, id, gin.H{"suspended": u.SuspendedAt != nil}, gin.H{"suspended": suspended})
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+// setRole godoc
+// @Summary Cambiar el rol de un usuario
+// @Description Asigna el rol user, moderator o admin. Los moderadores acceden a /moderation y los administradores además a /admin y /import. Un administrador no puede cambiar su propio rol. Solo para administradores
+// @Tags administración
+// @Accept json
+// @Produce json
+// @Param id path int true "ID del usuario"
+// @Param Authorization header string true "Token Bearer del administrador"
+// @Param role body user.RoleInput true "Rol"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/users/{id}/role [put]
+func setRole(c *gin.Context, users *userRepo.Repository, audits *auditRepo.Repository) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	if id == viewerID(c) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No puede aplicarse a su propia cuenta"})
+		return
+	}
+
+	var input user.RoleInput
+	if err := c.ShouldBindJSON(&input); err != nil || !user.ValidRole(input.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Rol inválido"})
+		return
+	}
+
+	u, err := users.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al buscar el usuario"})
+		return
+	}
+	if u == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+		return
+	}
+
+	if err := users.SetRole(id, input.Role); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo cambiar el rol"})
+		return
+	}
+	recordAudit(c, audits, audit.ActionUserSetRole, audit.TargetUser, id, gin.H{"role": u.Role}, gin.H{"role": input.Role})
+	c.JSON(http.StatusOK, gin.H{"message": "Rol actualizado"})
+}
+
+// forceDeleteTweet godoc
+// @Summary Eliminar un tweet definitivamente
+// @Description Elimina el tweet de inmediato y sin posibilidad de restaurarlo, aunque ya estuviera eliminado. Solo para administradores
+// @Tags administración
+// @Produce json
+// @Param id path int true "ID del tweet"
+// @Param Authorization header string true "Token Bearer del administrador"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/tweets/{id} [delete]
+func forceDeleteTweet(c *gin.Context, tweets *tweetRepo.Repository, audits *auditRepo.Repository) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	message, deleted, err := tweets.Message(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo eliminar el tweet"})
+		return
+	}
+
+	err = tweets.ForceDelete(id)
+	if errors.Is(err, tweetRepo.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tweet no encontrado"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo eliminar el tweet"})
+		return
+	}
+	recordAudit(c, audits, audit.ActionTweetForceDelete, audit.TargetTweet, id, gin.H{"message": message, "deleted": deleted}, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Tweet eliminado definitivamente"})
+}
+
+// getStats godoc
+// @Summary Obtener estadísticas del sistema
+// @Description Devuelve la cantidad de usuarios por estado, tweets, follows, archivos y denuncias abiertas. Solo para administradores
+// @Tags administración
+// @Produce json
+// @Param Authorization header string true "Token Bearer del administrador"
+// @Success 200 {object} admin.Stats
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/stats [get]
+func getStats(c *gin.Context, repo *adminRepo.Repository) {
+	stats, err := repo.Stats()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron obtener las estadísticas"})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// rebuildTimelines godoc
+// @Summary Reconstruir los timelines
+// @Description Recalcula los contadores y reconstruye los seguidores, seguidos y feeds de todos los usuarios a partir de las tablas follows y tweets. Solo para administradores
+// @Tags administración
+// @Produce json
+// @Param Authorization header string true "Token Bearer del administrador"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/timelines/rebuild [post]
+func rebuildTimelines(c *gin.Context, users *userRepo.Repository, audits *auditRepo.Repository) {
+	if _, err := users.RecomputeCounters(); err != nil {
+		log.Printf("⚠️ Could not recompute counters: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron reconstruir los timelines"})
+		return
+	}
+	updated, err := users.RebuildTimelines()
+	if err != nil {
+		log.Printf("⚠️ Could not rebuild timelines: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron reconstruir los timelines"})
+		return
+	}
+	recordAudit(c, audits, audit.ActionTimelinesRebuild, audit.TargetTimelines, 0, nil, gin.H{"users": updated})
+	c.JSON(http.StatusOK, gin.H{"message": "Timelines reconstruidos", "users": updated})
+}
@@ -2,15 +2,68 @@ package api
 
 import (
 	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
+	"ualabackend/archive"
 	"ualabackend/db"
+	"ualabackend/entities/user"
+	"ualabackend/filter"
+	"ualabackend/importer"
+	"ualabackend/jobs"
+	"ualabackend/ranking"
+	accountRepo "ualabackend/repositories/account"
+	adminRepo "ualabackend/repositories/admin"
+	auditRepo "ualabackend/repositories/audit"
+	blockRepo "ualabackend/repositories/block"
+	conversationRepo "ualabackend/repositories/conversation"
+	draftRepo "ualabackend/repositories/draft"
+	exportRepo "ualabackend/repositories/export"
 	followRepo "ualabackend/repositories/follow"
+	linkRepo "ualabackend/repositories/link"
+	listRepo "ualabackend/repositories/list"
+	mediaRepo "ualabackend/repositories/media"
+	moderationRepo "ualabackend/repositories/moderation"
+	pollRepo "ualabackend/repositories/poll"
+	trendRepo "ualabackend/repositories/trend"
 	tweetRepo "ualabackend/repositories/tweet"
 	userRepo "ualabackend/repositories/user"
+	"ualabackend/search"
+	"ualabackend/storage"
+	"ualabackend/suggestions"
+	"ualabackend/trends"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+
+	suggestionsTTL = time.Hour
+
+	purgeInterval            = time.Hour
+	defaultDeletionRetention = 30 * 24 * time.Hour
+	defaultDeactivationGrace = 30 * 24 * time.Hour
+
+	scheduleInterval = 10 * time.Second
+
+	trendsCheckpointInterval = 5 * time.Minute
+
+	defaultExportDir = "exports"
+	defaultMediaDir  = "uploads"
+	defaultExportTTL = 7 * 24 * time.Hour
+
+	// authHeader carries the bearer token of the user performing the
+	// request, as "Bearer <token>".
+	authHeader = "Authorization"
+	// viewerKey holds the authenticated user ID in the request context.
+	viewerKey = "viewer_id"
 )
 
 func InitAPI() {
@@ -19,12 +72,56 @@ func InitAPI() {
 		log.Fatal("❌ Could not initialize database:", err)
 	}
 
+	signer := signerFromEnv()
 	router := gin.Default()
+	if err := router.SetTrustedProxies(trustedProxiesFromEnv()); err != nil {
+		log.Fatal("❌ Invalid TRUSTED_PROXIES:", err)
+	}
+	router.Use(requestID(), authenticate(signer))
 	router.GET("/api/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	accountRepository := accountRepo.NewRepository(database)
+	blockRepository := blockRepo.NewRepository(database)
+	conversationRepository := conversationRepo.NewRepository(database)
+	draftRepository := draftRepo.NewRepository(database)
+	exportRepository := exportRepo.NewRepository(database)
 	followRepository := followRepo.NewRepository(database)
-	tweetRepository := tweetRepo.NewRepository(database)
-	userRepository := userRepo.NewRepository(database)
+	listRepository := listRepo.NewRepository(database)
+	mediaRepository := mediaRepo.NewRepository(database)
+	moderationRepository := moderationRepo.NewRepository(database)
+	adminRepository := adminRepo.NewRepository(database)
+	auditRepository := auditRepo.NewRepository(database)
+	pollRepository := pollRepo.NewRepository(database)
+	trendRepository := trendRepo.NewRepository(database)
+	tweetRepository := tweetRepo.NewRepository(database, search.NewMemoryIndex())
+	tweetRepository.Edits = editPolicyFromEnv()
+	tweetRepository.LinkBase = stringFromEnv("LINK_BASE_URL", tweetRepo.DefaultLinkBase)
+	tweetRepository.Filter = contentFilterFromEnv()
+	linkRepository := linkRepo.NewRepository(database, tweetRepository.LinkBase)
+	userRepository := userRepo.NewRepository(database, search.NewNameIndex())
+	userRepository.Tweets = tweetRepository.Index
+	router.Use(activeViewer(userRepository))
+
+	docs, err := tweetRepository.Documents()
+	if err != nil {
+		log.Fatal("❌ Could not load tweets into the search index:", err)
+	}
+	if err := search.Rebuild(tweetRepository.Index, docs); err != nil {
+		log.Fatal("❌ Could not build the search index:", err)
+	}
+	if err := userRepository.IndexNames(); err != nil {
+		log.Fatal("❌ Could not build the user name index:", err)
+	}
+	go jobs.Every(purgeInterval, "rank-user-names", userRepository.RankNames)
+
+	tracker := trends.NewTracker(trends.DefaultConfig, nil)
+	if err := restoreTrends(tracker, trendRepository, docs); err != nil {
+		log.Printf("⚠️ Could not restore trends: %v", err)
+	}
+	tweetRepository.Trends = tracker
+	go jobs.Every(trendsCheckpointInterval, "checkpoint-trends", func() error {
+		return tracker.Save(trendRepository)
+	})
 
 	userRepository.Create("Usuario1")
 	userRepository.Create("Usuario2")
@@ -35,10 +132,314 @@ func InitAPI() {
 	tweetRepository.Create(1, "esto es una prueba")
 	tweetRepository.Create(2, "esto tambien")
 	tweetRepository.Create(3, "ola")
-	userRoutes(router, userRepository)
-	tweetRoutes(router, tweetRepository)
-	followRoutes(router, followRepository)
+	suggester := suggestions.NewService(followRepository, suggestionsTTL)
+	go jobs.Every(purgeInterval, "prune-suggestions", suggester.Prune)
+
+	grace := durationFromEnv("ACCOUNT_DEACTIVATION_GRACE", defaultDeactivationGrace)
+	go jobs.Every(purgeInterval, "delete-deactivated-accounts", func() error {
+		return deleteDeactivatedAccounts(userRepository, accountRepository, tweetRepository, suggester, grace)
+	})
+
+	exportDir := stringFromEnv("EXPORT_DIR", defaultExportDir)
+	archiver := archive.NewService(exportRepository, userRepository, tweetRepository, followRepository,
+		exportDir, durationFromEnv("EXPORT_TTL", defaultExportTTL))
+	if err := archiver.Resume(); err != nil {
+		log.Printf("⚠️ Could not resume pending exports: %v", err)
+	}
+	go jobs.Every(purgeInterval, "cleanup-exports", archiver.Cleanup)
+
+	authRoutes(router, signer, userRepository)
+	userRoutes(router, userRepository, followRepository, suggester, signer, auditRepository, grace)
+	retention := durationFromEnv("TWEET_DELETE_RETENTION", defaultDeletionRetention)
+	go jobs.Every(purgeInterval, "purge-deleted-tweets", func() error {
+		purged, err := tweetRepository.PurgeDeleted(retention)
+		if purged > 0 {
+			log.Printf("🧹 Purged %d deleted tweets", purged)
+		}
+		return err
+	})
+
+	go jobs.Every(scheduleInterval, "publish-scheduled-tweets", func() error {
+		published, err := tweetRepository.PublishDue(time.Now())
+		if published > 0 {
+			log.Printf("🕒 Published %d scheduled tweets", published)
+		}
+		return err
+	})
+
+	blobs := storage.NewLocalFS(stringFromEnv("MEDIA_DIR", defaultMediaDir))
+	go jobs.Every(purgeInterval, "delete-orphaned-media", func() error {
+		return deleteOrphanedMedia(mediaRepository, blobs)
+	})
+
+	entities := tweetEntities{media: mediaRepository, links: linkRepository}
+	tweetRoutes(router, tweetRepository, pollRepository, userRepository, entities, auditRepository, retention)
+	mediaRoutes(router, mediaRepository, blobs, tweetRepository)
+	pollRoutes(router, pollRepository, tweetRepository)
+	scheduledRoutes(router, tweetRepository, userRepository, auditRepository)
+	draftRoutes(router, draftRepository, tweetRepository, userRepository, auditRepository)
+	followRoutes(router, followRepository, auditRepository)
+	blockRoutes(router, blockRepository, userRepository)
+	ranker := ranking.NewRanker(tweetRepository, ranking.DefaultFactors, nil)
+	timelineRoutes(router, tweetRepository, userRepository, ranker, entities)
+	linkRoutes(router, linkRepository)
+	conversationRoutes(router, conversationRepository, userRepository, auditRepository)
+	listRoutes(router, listRepository, tweetRepository, userRepository)
+	exportRoutes(router, archiver, exportRepository, userRepository)
+	importRoutes(router, importer.New(database, userRepository), tweetRepository, userRepository, auditRepository)
+	searchRoutes(router, tweetRepository, userRepository)
+	trendRoutes(router, tracker)
+	moderationRoutes(router, moderationRepository, tweetRepository, userRepository, auditRepository)
+	adminRoutes(router, adminRepository, userRepository, tweetRepository, moderationRepository, auditRepository)
 
 	router.Run(":9090")
 
 }
+
+// deleteDeactivatedAccounts runs the deletion pipeline for every account
+// deactivated longer than grace ago.
+func deleteDeactivatedAccounts(users *userRepo.Repository, accounts *accountRepo.Repository, tweets *tweetRepo.Repository, suggester *suggestions.Service, grace time.Duration) error {
+	ids, err := users.DeactivatedBefore(time.Now().Add(-grace))
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		deleted, err := accounts.Delete(id)
+		if err != nil {
+			log.Printf("⚠️ Could not delete account %d: %v", id, err)
+			continue
+		}
+		for _, tweetID := range deleted.TweetIDs {
+			if err := tweets.Index.Remove(tweetID); err != nil {
+				log.Printf("⚠️ Could not remove tweet %d from the search index: %v", tweetID, err)
+			}
+		}
+		for _, path := range deleted.ExportFiles {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				log.Printf("⚠️ Could not remove export %s: %v", path, err)
+			}
+		}
+		users.Names.Remove(id)
+		suggester.Evict(id)
+		log.Printf("🗑️ Deleted account %d and %d tweets", id, len(deleted.TweetIDs))
+	}
+	return nil
+}
+
+// restoreTrends loads the last trends checkpoint and replays the tweets
+// published after it was taken. Without a checkpoint every tweet is replayed.
+func restoreTrends(tracker *trends.Tracker, store trends.Store, docs []search.Document) error {
+	cp, err := store.LoadCheckpoint()
+	if err != nil {
+		return err
+	}
+
+	var since time.Time
+	if cp != nil {
+		if err := tracker.Restore(*cp); err != nil {
+			return err
+		}
+		since = cp.At
+	}
+	for _, d := range docs {
+		if d.CreatedAt.After(since) {
+			tracker.Record(d.Text, d.CreatedAt)
+		}
+	}
+	return nil
+}
+
+// editPolicyFromEnv reads TWEET_EDIT_WINDOW (a Go duration such as "30m")
+// and TWEET_MAX_EDITS, falling back to the defaults when unset or invalid.
+func editPolicyFromEnv() tweetRepo.EditPolicy {
+	policy := tweetRepo.DefaultEditPolicy
+
+	policy.Window = durationFromEnv("TWEET_EDIT_WINDOW", policy.Window)
+	if v := os.Getenv("TWEET_MAX_EDITS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			policy.MaxEdits = n
+		} else {
+			log.Printf("⚠️ Invalid TWEET_MAX_EDITS %q, using %d", v, policy.MaxEdits)
+		}
+	}
+	return policy
+}
+
+// contentFilterFromEnv builds the content filter from the JSON file named by
+// CONTENT_FILTER_CONFIG, or from the default rules when unset. An invalid
+// file stops the server rather than letting every message through.
+func contentFilterFromEnv() *filter.Pipeline {
+	cfg := filter.DefaultConfig
+	if path := os.Getenv("CONTENT_FILTER_CONFIG"); path != "" {
+		var err error
+		if cfg, err = filter.Load(path); err != nil {
+			log.Fatal("❌ Could not read the content filter configuration:", err)
+		}
+	}
+	pipeline, err := cfg.Build()
+	if err != nil {
+		log.Fatal("❌ Invalid content filter configuration:", err)
+	}
+	return pipeline
+}
+
+// trustedProxiesFromEnv reads TRUSTED_PROXIES, a comma-separated list of the
+// IPs or CIDRs of the reverse proxies in front of the API. Only those may set
+// the client IP through X-Forwarded-For; when unset no proxy is trusted and
+// the client IP is the address of the connection.
+func trustedProxiesFromEnv() []string {
+	var proxies []string
+	for _, p := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
+	return proxies
+}
+
+// stringFromEnv returns the named variable, or fallback when it is unset.
+func stringFromEnv(name, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// durationFromEnv parses the named variable as a Go duration, returning
+// fallback when it is unset or invalid.
+func durationFromEnv(name string, fallback time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Printf("⚠️ Invalid %s %q, using %s", name, v, fallback)
+		return fallback
+	}
+	return d
+}
+
+// pagination reads the limit and offset query parameters. On invalid input it
+// writes a 400 response and returns ok == false.
+func pagination(c *gin.Context) (limit, offset int, ok bool) {
+	limit, err1 := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultPageSize)))
+	offset, err2 := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err1 != nil || err2 != nil || limit <= 0 || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parámetros de paginación inválidos"})
+		return 0, 0, false
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+	return limit, offset, true
+}
+
+// viewerID returns the ID of the authenticated user performing the request,
+// or 0 when the request is anonymous.
+func viewerID(c *gin.Context) int {
+	return c.GetInt(viewerKey)
+}
+
+// requireViewer is like viewerID but writes a 401 response when the request
+// is anonymous.
+func requireViewer(c *gin.Context) (int, bool) {
+	id := viewerID(c)
+	if id == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Se requiere un token de acceso"})
+		return 0, false
+	}
+	return id, true
+}
+
+// requireRole returns a middleware that only lets through requests from
+// active users granted role. It writes a 401 response when the request does
+// not identify its user and a 403 one when the user lacks the role.
+func requireRole(users *userRepo.Repository, role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := requireViewer(c)
+		if !ok {
+			c.Abort()
+			return
+		}
+		u, err := users.GetByID(id)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error al verificar los permisos"})
+			return
+		}
+		if u == nil || u.DeactivatedAt != nil || u.SuspendedAt != nil || !user.HasRole(u.Role, role) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "No tiene permisos para acceder a este recurso"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// requireOwner returns a middleware that only lets through requests from
+// the owner of the resource, as resolved by ownerOf. ownerOf writes the
+// error response when it cannot resolve the owner.
+func requireOwner(ownerOf func(c *gin.Context) (int, bool)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		viewer, ok := requireViewer(c)
+		if !ok {
+			c.Abort()
+			return
+		}
+		owner, ok := ownerOf(c)
+		if !ok {
+			c.Abort()
+			return
+		}
+		if owner != viewer {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Solo el dueño puede modificar este recurso"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// requireOwnerOrModerator is like requireOwner but also lets through active
+// moderators. It is meant for taking content down; moderators change
+// everything else through the moderation actions, which are reviewed and
+// recorded.
+func requireOwnerOrModerator(users *userRepo.Repository, ownerOf func(c *gin.Context) (int, bool)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		viewer, ok := requireViewer(c)
+		if !ok {
+			c.Abort()
+			return
+		}
+		owner, ok := ownerOf(c)
+		if !ok {
+			c.Abort()
+			return
+		}
+		if owner == viewer {
+			c.Next()
+			return
+		}
+		u, err := users.GetByID(viewer)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error al verificar los permisos"})
+			return
+		}
+		if u == nil || u.DeactivatedAt != nil || u.SuspendedAt != nil || !user.HasRole(u.Role, user.RoleModerator) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Solo el dueño o un moderador pueden eliminar este recurso"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// pathOwner resolves the owner of a resource from a user ID in the path.
+func pathOwner(param string) func(c *gin.Context) (int, bool) {
+	return func(c *gin.Context) (int, bool) {
+		id, err := strconv.Atoi(c.Param(param))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+			return 0, false
+		}
+		return id, true
+	}
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ualabackend/entities/audit"
+	auditRepo "ualabackend/repositories/audit"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// requestIDHeader carries the ID that ties a request to its audit
+	// entries. Clients may send their own; otherwise one is generated.
+	requestIDHeader = "X-Request-ID"
+	requestIDKey    = "request_id"
+
+	maxRequestIDLength = 64
+)
+
+// requestID returns a middleware that assigns every request an ID, echoed
+// in the response headers.
+func requestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if id == "" || len(id) > maxRequestIDLength {
+			b := make([]byte, 8)
+			if _, err := rand.Read(b); err != nil {
+				log.Printf("⚠️ Could not generate a request ID: %v", err)
+			}
+			id = hex.EncodeToString(b)
+		}
+		c.Set(requestIDKey, id)
+		c.Header(requestIDHeader, id)
+		c.Next()
+	}
+}
+
+// recordAudit appends an entry to the audit log for an operation the request
+// performed on the target, with the fields that differ between before and
+// after. The operation already took effect, so a failure to record it is
+// logged rather than reported to the client.
+func recordAudit(c *gin.Context, repo *auditRepo.Repository, action, targetType string, targetID int, before, after interface{}) {
+	diff, err := audit.Diff(before, after)
+	if err != nil {
+		log.Printf("⚠️ Could not audit %s on %s %d: %v", action, targetType, targetID, err)
+		return
+	}
+	err = repo.Append(audit.Entry{
+		ActorID:    viewerID(c),
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Diff:       diff,
+		RequestID:  c.GetString(requestIDKey),
+		IP:         c.ClientIP(),
+	})
+	if err != nil {
+		log.Printf("⚠️ Could not audit %s on %s %d: %v", action, targetType, targetID, err)
+	}
+}
+
+// getAuditLog godoc
+// @Summary Consultar el registro de auditoría
+// @Description Devuelve las operaciones que modificaron usuarios, tweets y follows, de la más reciente a la más antigua, con quién las hizo, los campos que cambiaron, el ID de la petición y la IP de origen. Solo para administradores
+// @Tags administración
+// @Produce json
+// @Param Authorization header string true "Token Bearer del administrador"
+// @Param actor_id query int false "ID del usuario que realizó la operación"
+// @Param action query string false "Operación, por ejemplo tweet.update o follow.create"
+// @Param target_type query string false "Tipo de objetivo: user, tweet, scheduled_tweet o timelines"
+// @Param target_id query int false "ID del objetivo"
+// @Param since query string false "Desde esta fecha (RFC 3339)"
+// @Param until query string false "Hasta esta fecha, exclusive (RFC 3339)"
+// @Param limit query int false "Cantidad máxima de resultados"
+// @Param offset query int false "Desplazamiento para paginar"
+// @Success 200 {array} audit.Entry
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/audit [get]
+func getAuditLog(c *gin.Context, repo *auditRepo.Repository) {
+	limit, offset, ok := pagination(c)
+	if !ok {
+		return
+	}
+
+	f := audit.Filter{
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+	}
+	var err error
+	if v := c.Query("actor_id"); v != "" {
+		if f.ActorID, err = strconv.Atoi(v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+			return
+		}
+	}
+	if v := c.Query("target_id"); v != "" {
+		if f.TargetID, err = strconv.Atoi(v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+			return
+		}
+	}
+	if v := c.Query("since"); v != "" {
+		if f.Since, err = time.Parse(time.RFC3339, v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Fecha inválida"})
+			return
+		}
+	}
+	if v := c.Query("until"); v != "" {
+		if f.Until, err = time.Parse(time.RFC3339, v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Fecha inválida"})
+			return
+		}
+	}
+
+	entries, err := repo.Query(f, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo obtener el registro de auditoría"})
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
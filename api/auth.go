@@ -0,0 +1,129 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"ualabackend/auth"
+	"ualabackend/entities/user"
+	userRepo "ualabackend/repositories/user"
+
+	"github.com/gin-gonic/gin"
+)
+
+func authRoutes(router *gin.Engine, signer *auth.Signer, users *userRepo.Repository) {
+	router.POST("/auth/token", func(c *gin.Context) { refreshToken(c, signer, users) })
+}
+
+// authenticate returns a middleware that verifies the bearer token of the
+// request, if any, and stores the user it identifies in the context.
+// Requests without a token go through as anonymous; requests with an
+// invalid or expired one are rejected.
+func authenticate(signer *auth.Signer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader(authHeader)
+		if header == "" {
+			c.Next()
+			return
+		}
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token inválido o vencido"})
+			return
+		}
+		id, err := signer.Verify(strings.TrimSpace(token))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token inválido o vencido"})
+			return
+		}
+		c.Set(viewerKey, id)
+		c.Next()
+	}
+}
+
+// inactiveAllowed lists the routes a deactivated account may still call: it
+// needs a token to cancel its deactivation.
+var inactiveAllowed = map[string]bool{
+	"/auth/token":           true,
+	"/users/:id/reactivate": true,
+}
+
+// activeViewer returns a middleware that rejects authenticated requests
+// from suspended accounts, and from deactivated ones outside
+// inactiveAllowed, so their tokens stop working until the account is
+// restored. It must run after authenticate.
+func activeViewer(users *userRepo.Repository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := viewerID(c)
+		if id == 0 {
+			c.Next()
+			return
+		}
+		u, err := users.GetByID(id)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error al verificar la cuenta"})
+			return
+		}
+		if u == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token inválido o vencido"})
+			return
+		}
+		if u.SuspendedAt != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "La cuenta está suspendida"})
+			return
+		}
+		if u.DeactivatedAt != nil && !inactiveAllowed[c.FullPath()] {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "La cuenta está desactivada"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// signerFromEnv builds the token signer from AUTH_SECRET and AUTH_TOKEN_TTL.
+// The API refuses to start without a secret: a generated one would lock
+// every user out on the next restart, and tokens issued with
+// ./main issue-token would never verify.
+func signerFromEnv() *auth.Signer {
+	secret := os.Getenv("AUTH_SECRET")
+	if secret == "" {
+		log.Fatal("❌ AUTH_SECRET is not set")
+	}
+	return auth.NewSigner([]byte(secret), durationFromEnv("AUTH_TOKEN_TTL", auth.DefaultTTL))
+}
+
+// issueToken responds with a new token for the user.
+func issueToken(c *gin.Context, signer *auth.Signer, status int, userID int) {
+	token, expires := signer.Issue(userID)
+	c.JSON(status, user.Token{Token: token, ExpiresAt: expires})
+}
+
+// refreshToken godoc
+// @Summary Renovar el token de acceso
+// @Description Devuelve un token nuevo para el usuario autenticado, con el vencimiento renovado
+// @Tags autenticación
+// @Produce json
+// @Param Authorization header string true "Token Bearer del usuario"
+// @Success 200 {object} user.Token
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/token [post]
+func refreshToken(c *gin.Context, signer *auth.Signer, users *userRepo.Repository) {
+	id, ok := requireViewer(c)
+	if !ok {
+		return
+	}
+	u, err := users.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al buscar usuario"})
+		return
+	}
+	if u == nil || u.SuspendedAt != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "La cuenta no puede iniciar sesión"})
+		return
+	}
+	issueToken(c, signer, http.StatusOK, id)
+}
@@ -0,0 +1,216 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	block "ualabackend/entities/block"
+	blockRepo "ualabackend/repositories/block"
+	userRepo "ualabackend/repositories/user"
+
+	"github.com/gin-gonic/gin"
+)
+
+func blockRoutes(router *gin.Engine, repo *blockRepo.Repository, users *userRepo.Repository) {
+	u := router.Group("/users")
+	{
+		u.GET("/:id/blocks", func(c *gin.Context) { getBlocks(c, repo, users) })
+		u.POST("/:id/blocks", func(c *gin.Context) { createBlock(c, repo, users) })
+		u.DELETE("/:id/blocks/:target_id", func(c *gin.Context) { deleteBlock(c, repo) })
+		u.GET("/:id/mutes", func(c *gin.Context) { getMutes(c, repo, users) })
+		u.POST("/:id/mutes", func(c *gin.Context) { createMute(c, repo, users) })
+		u.DELETE("/:id/mutes/:target_id", func(c *gin.Context) { deleteMute(c, repo) })
+	}
+}
+
+// getBlocks godoc
+// @Summary Listar usuarios bloqueados
+// @Description Devuelve los usuarios bloqueados por el usuario indicado
+// @Tags bloqueos
+// @Produce json
+// @Param id path int true "ID del usuario"
+// @Param limit query int false "Cantidad máxima de resultados"
+// @Param offset query int false "Desplazamiento para paginar"
+// @Success 200 {array} user.UserSummary
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/blocks [get]
+func getBlocks(c *gin.Context, repo *blockRepo.Repository, users *userRepo.Repository) {
+	id, ok := existingUserID(c, users)
+	if !ok {
+		return
+	}
+	limit, offset, ok := pagination(c)
+	if !ok {
+		return
+	}
+
+	blocked, err := repo.GetBlocked(id, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener bloqueos"})
+		return
+	}
+	c.JSON(http.StatusOK, blocked)
+}
+
+// createBlock godoc
+// @Summary Bloquear un usuario
+// @Description Bloquea a un usuario. Elimina los follows existentes en ambas direcciones e impide volver a seguirse
+// @Tags bloqueos
+// @Accept json
+// @Produce json
+// @Param id path int true "ID del usuario que bloquea"
+// @Param block body block.TargetInput true "Usuario a bloquear"
+// @Success 201 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/blocks [post]
+func createBlock(c *gin.Context, repo *blockRepo.Repository, users *userRepo.Repository) {
+	id, targetID, ok := relationTarget(c, users)
+	if !ok {
+		return
+	}
+
+	if err := repo.Block(id, targetID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo bloquear al usuario"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Usuario bloqueado"})
+}
+
+// deleteBlock godoc
+// @Summary Desbloquear un usuario
+// @Description Elimina un bloqueo. Los follows eliminados al bloquear no se restauran
+// @Tags bloqueos
+// @Produce json
+// @Param id path int true "ID del usuario que bloqueó"
+// @Param target_id path int true "ID del usuario bloqueado"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/blocks/{target_id} [delete]
+func deleteBlock(c *gin.Context, repo *blockRepo.Repository) {
+	id, err1 := strconv.Atoi(c.Param("id"))
+	targetID, err2 := strconv.Atoi(c.Param("target_id"))
+	if err1 != nil || err2 != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "IDs inválidos"})
+		return
+	}
+
+	if err := repo.Unblock(id, targetID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo desbloquear al usuario"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Usuario desbloqueado"})
+}
+
+// getMutes godoc
+// @Summary Listar usuarios silenciados
+// @Description Devuelve los usuarios silenciados por el usuario indicado
+// @Tags bloqueos
+// @Produce json
+// @Param id path int true "ID del usuario"
+// @Param limit query int false "Cantidad máxima de resultados"
+// @Param offset query int false "Desplazamiento para paginar"
+// @Success 200 {array} user.UserSummary
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/mutes [get]
+func getMutes(c *gin.Context, repo *blockRepo.Repository, users *userRepo.Repository) {
+	id, ok := existingUserID(c, users)
+	if !ok {
+		return
+	}
+	limit, offset, ok := pagination(c)
+	if !ok {
+		return
+	}
+
+	muted, err := repo.GetMuted(id, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener silenciados"})
+		return
+	}
+	c.JSON(http.StatusOK, muted)
+}
+
+// createMute godoc
+// @Summary Silenciar un usuario
+// @Description Oculta los tweets del usuario silenciado en el timeline sin dejar de seguirlo
+// @Tags bloqueos
+// @Accept json
+// @Produce json
+// @Param id path int true "ID del usuario que silencia"
+// @Param mute body block.TargetInput true "Usuario a silenciar"
+// @Success 201 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/mutes [post]
+func createMute(c *gin.Context, repo *blockRepo.Repository, users *userRepo.Repository) {
+	id, targetID, ok := relationTarget(c, users)
+	if !ok {
+		return
+	}
+
+	if err := repo.Mute(id, targetID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo silenciar al usuario"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Usuario silenciado"})
+}
+
+// deleteMute godoc
+// @Summary Dejar de silenciar un usuario
+// @Description Elimina un silenciamiento
+// @Tags bloqueos
+// @Produce json
+// @Param id path int true "ID del usuario que silenció"
+// @Param target_id path int true "ID del usuario silenciado"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/mutes/{target_id} [delete]
+func deleteMute(c *gin.Context, repo *blockRepo.Repository) {
+	id, err1 := strconv.Atoi(c.Param("id"))
+	targetID, err2 := strconv.Atoi(c.Param("target_id"))
+	if err1 != nil || err2 != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "IDs inválidos"})
+		return
+	}
+
+	if err := repo.Unmute(id, targetID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo dejar de silenciar al usuario"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Usuario ya no silenciado"})
+}
+
+// relationTarget reads the acting user from the path and the target user from
+// the body, checking that both exist and are different.
+func relationTarget(c *gin.Context, users *userRepo.Repository) (int, int, bool) {
+	id, ok := existingUserID(c, users)
+	if !ok {
+		return 0, 0, false
+	}
+
+	var payload block.TargetInput
+	if err := c.ShouldBindJSON(&payload); err != nil || payload.TargetID == id {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos"})
+		return 0, 0, false
+	}
+
+	target, err := users.GetByID(payload.TargetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al buscar usuario"})
+		return 0, 0, false
+	}
+	if target == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+		return 0, 0, false
+	}
+	return id, payload.TargetID, true
+}
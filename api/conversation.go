@@ -0,0 +1,356 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"ualabackend/entities/audit"
+	conversation "ualabackend/entities/conversation"
+	auditRepo "ualabackend/repositories/audit"
+	conversationRepo "ualabackend/repositories/conversation"
+	userRepo "ualabackend/repositories/user"
+
+	"github.com/gin-gonic/gin"
+)
+
+func conversationRoutes(router *gin.Engine, repo *conversationRepo.Repository, users *userRepo.Repository, audits *auditRepo.Repository) {
+	conversations := router.Group("/conversations")
+	{
+		conversations.GET("/", func(c *gin.Context) { getConversations(c, repo) })
+		conversations.POST("/", func(c *gin.Context) { createConversation(c, repo) })
+		conversations.GET("/:id", func(c *gin.Context) { getConversation(c, repo) })
+		conversations.DELETE("/:id", func(c *gin.Context) { clearConversation(c, repo) })
+		conversations.GET("/:id/messages", func(c *gin.Context) { getMessages(c, repo) })
+		conversations.POST("/:id/messages", func(c *gin.Context) { sendMessage(c, repo) })
+		conversations.DELETE("/:id/messages/:message_id", func(c *gin.Context) { deleteMessage(c, repo) })
+		conversations.POST("/:id/read", func(c *gin.Context) { markConversationRead(c, repo) })
+	}
+
+	router.PUT("/users/:id/dm-settings", requireOwner(pathOwner("id")), func(c *gin.Context) { updateDMSettings(c, users, audits) })
+}
+
+// getConversations godoc
+// @Summary Listar conversaciones
+// @Description Devuelve las conversaciones del usuario, la de actividad más reciente primero
+// @Tags mensajes
+// @Produce json
+// @Param Authorization header string true "Token Bearer del usuario"
+// @Param limit query int false "Cantidad máxima de resultados"
+// @Param offset query int false "Desplazamiento para paginar"
+// @Success 200 {array} conversation.Conversation
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /conversations/ [get]
+func getConversations(c *gin.Context, repo *conversationRepo.Repository) {
+	userID, ok := requireViewer(c)
+	if !ok {
+		return
+	}
+	limit, offset, ok := pagination(c)
+	if !ok {
+		return
+	}
+
+	conversations, err := repo.List(userID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener conversaciones"})
+		return
+	}
+	c.JSON(http.StatusOK, conversations)
+}
+
+// createConversation godoc
+// @Summary Crear una conversación
+// @Description Crea una conversación 1:1 (o devuelve la existente) o grupal. Solo se permite con seguidores mutuos o usuarios que aceptan mensajes de cualquiera
+// @Tags mensajes
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Token Bearer del usuario"
+// @Param conversation body conversation.ConversationInput true "Participantes"
+// @Success 201 {object} conversation.Conversation
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /conversations/ [post]
+func createConversation(c *gin.Context, repo *conversationRepo.Repository) {
+	userID, ok := requireViewer(c)
+	if !ok {
+		return
+	}
+
+	var payload conversation.ConversationInput
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos"})
+		return
+	}
+
+	conv, err := repo.Create(userID, payload.ParticipantIDs)
+	if err != nil {
+		conversationError(c, err, "No se pudo crear la conversación")
+		return
+	}
+	c.JSON(http.StatusCreated, conv)
+}
+
+// getConversation godoc
+// @Summary Obtener una conversación
+// @Description Devuelve una conversación del usuario
+// @Tags mensajes
+// @Produce json
+// @Param Authorization header string true "Token Bearer del usuario"
+// @Param id path int true "ID de la conversación"
+// @Success 200 {object} conversation.Conversation
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /conversations/{id} [get]
+func getConversation(c *gin.Context, repo *conversationRepo.Repository) {
+	userID, id, ok := conversationParams(c)
+	if !ok {
+		return
+	}
+
+	conv, err := repo.Get(id, userID)
+	if err != nil {
+		conversationError(c, err, "Error al obtener la conversación")
+		return
+	}
+	c.JSON(http.StatusOK, conv)
+}
+
+// clearConversation godoc
+// @Summary Eliminar una conversación
+// @Description Oculta el historial de la conversación solo para el usuario. Los nuevos mensajes se siguen recibiendo
+// @Tags mensajes
+// @Produce json
+// @Param Authorization header string true "Token Bearer del usuario"
+// @Param id path int true "ID de la conversación"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /conversations/{id} [delete]
+func clearConversation(c *gin.Context, repo *conversationRepo.Repository) {
+	userID, id, ok := conversationParams(c)
+	if !ok {
+		return
+	}
+
+	if err := repo.Clear(id, userID); err != nil {
+		conversationError(c, err, "No se pudo eliminar la conversación")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Conversación eliminada"})
+}
+
+// getMessages godoc
+// @Summary Historial de mensajes
+// @Description Devuelve los mensajes de la conversación del más reciente al más antiguo. Para paginar se pasa en before el ID del último mensaje recibido
+// @Tags mensajes
+// @Produce json
+// @Param Authorization header string true "Token Bearer del usuario"
+// @Param id path int true "ID de la conversación"
+// @Param before query int false "Devolver mensajes con ID menor a este"
+// @Param limit query int false "Cantidad máxima de resultados"
+// @Success 200 {array} conversation.Message
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /conversations/{id}/messages [get]
+func getMessages(c *gin.Context, repo *conversationRepo.Repository) {
+	userID, id, ok := conversationParams(c)
+	if !ok {
+		return
+	}
+	limit, _, ok := pagination(c)
+	if !ok {
+		return
+	}
+	before, err := strconv.Atoi(c.DefaultQuery("before", "0"))
+	if err != nil || before < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cursor inválido"})
+		return
+	}
+
+	messages, err := repo.History(id, userID, before, limit)
+	if err != nil {
+		conversationError(c, err, "Error al obtener mensajes")
+		return
+	}
+	c.JSON(http.StatusOK, messages)
+}
+
+// sendMessage godoc
+// @Summary Enviar un mensaje
+// @Description Envía un mensaje a la conversación
+// @Tags mensajes
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Token Bearer del usuario"
+// @Param id path int true "ID de la conversación"
+// @Param message body conversation.MessageInput true "Mensaje"
+// @Success 201 {object} conversation.Message
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /conversations/{id}/messages [post]
+func sendMessage(c *gin.Context, repo *conversationRepo.Repository) {
+	userID, id, ok := conversationParams(c)
+	if !ok {
+		return
+	}
+
+	var payload conversation.MessageInput
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Mensaje requerido"})
+		return
+	}
+
+	m, err := repo.Send(id, userID, payload.Body)
+	if err != nil {
+		conversationError(c, err, "No se pudo enviar el mensaje")
+		return
+	}
+	c.JSON(http.StatusCreated, m)
+}
+
+// deleteMessage godoc
+// @Summary Eliminar un mensaje
+// @Description Oculta un mensaje solo para el usuario que lo elimina
+// @Tags mensajes
+// @Produce json
+// @Param Authorization header string true "Token Bearer del usuario"
+// @Param id path int true "ID de la conversación"
+// @Param message_id path int true "ID del mensaje"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /conversations/{id}/messages/{message_id} [delete]
+func deleteMessage(c *gin.Context, repo *conversationRepo.Repository) {
+	userID, id, ok := conversationParams(c)
+	if !ok {
+		return
+	}
+	messageID, err := strconv.Atoi(c.Param("message_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	found, err := repo.DeleteMessage(id, messageID, userID)
+	if err != nil {
+		conversationError(c, err, "No se pudo eliminar el mensaje")
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Mensaje no encontrado"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Mensaje eliminado"})
+}
+
+// markConversationRead godoc
+// @Summary Marcar como leído
+// @Description Registra la confirmación de lectura hasta el mensaje indicado, o hasta el último si no se indica
+// @Tags mensajes
+// @Produce json
+// @Param Authorization header string true "Token Bearer del usuario"
+// @Param id path int true "ID de la conversación"
+// @Param message_id query int false "ID del último mensaje leído"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /conversations/{id}/read [post]
+func markConversationRead(c *gin.Context, repo *conversationRepo.Repository) {
+	userID, id, ok := conversationParams(c)
+	if !ok {
+		return
+	}
+	messageID, err := strconv.Atoi(c.DefaultQuery("message_id", "0"))
+	if err != nil || messageID < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	if err := repo.MarkRead(id, userID, messageID); err != nil {
+		conversationError(c, err, "No se pudo marcar como leído")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Conversación marcada como leída"})
+}
+
+// updateDMSettings godoc
+// @Summary Configurar mensajes directos
+// @Description Permite recibir mensajes directos de cualquier usuario y no solo de seguidores mutuos
+// @Tags mensajes
+// @Accept json
+// @Produce json
+// @Param id path int true "ID del usuario"
+// @Param Authorization header string true "Token Bearer del usuario"
+// @Param settings body conversation.DMSettingsInput true "Configuración"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/dm-settings [put]
+func updateDMSettings(c *gin.Context, users *userRepo.Repository, audits *auditRepo.Repository) {
+	u, ok := existingUser(c, users)
+	if !ok {
+		return
+	}
+
+	var payload conversation.DMSettingsInput
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos"})
+		return
+	}
+
+	if err := users.SetAllowDMs(u.Id, *payload.AllowFromAnyone); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo actualizar el usuario"})
+		return
+	}
+	recordAudit(c, audits, audit.ActionUserSetDMs, audit.TargetUser, u.Id,
+		gin.H{"allow_dms_from_anyone": u.AllowDMs}, gin.H{"allow_dms_from_anyone": *payload.AllowFromAnyone})
+	c.JSON(http.StatusOK, gin.H{"message": "Usuario actualizado"})
+}
+
+func conversationParams(c *gin.Context) (userID, conversationID int, ok bool) {
+	userID, ok = requireViewer(c)
+	if !ok {
+		return 0, 0, false
+	}
+	conversationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return 0, 0, false
+	}
+	return userID, conversationID, true
+}
+
+// conversationError maps repository errors to responses. Non participants get
+// a 404 so conversation IDs do not leak.
+func conversationError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, conversationRepo.ErrNotParticipant):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conversación no encontrada"})
+	case errors.Is(err, conversationRepo.ErrNotAllowed):
+		c.JSON(http.StatusForbidden, gin.H{"error": "No se permite enviar mensajes directos a este usuario"})
+	case errors.Is(err, conversationRepo.ErrTooManyParticipants):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Demasiados participantes"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
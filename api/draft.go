@@ -0,0 +1,236 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"ualabackend/entities/audit"
+	draft "ualabackend/entities/draft"
+	"ualabackend/filter"
+	auditRepo "ualabackend/repositories/audit"
+	draftRepo "ualabackend/repositories/draft"
+	tweetRepo "ualabackend/repositories/tweet"
+	userRepo "ualabackend/repositories/user"
+
+	"github.com/gin-gonic/gin"
+)
+
+func draftRoutes(router *gin.Engine, repo *draftRepo.Repository, tweets *tweetRepo.Repository, users *userRepo.Repository, audits *auditRepo.Repository) {
+	router.GET("/users/:id/drafts", func(c *gin.Context) { getDrafts(c, repo, users) })
+	router.POST("/users/:id/drafts", func(c *gin.Context) { createDraft(c, repo, users) })
+	router.PUT("/users/:id/drafts/:draft_id", func(c *gin.Context) { updateDraft(c, repo, users) })
+	router.DELETE("/users/:id/drafts/:draft_id", func(c *gin.Context) { deleteDraft(c, repo, users) })
+	router.POST("/users/:id/drafts/:draft_id/publish", func(c *gin.Context) { publishDraft(c, repo, tweets, users, audits) })
+}
+
+// getDrafts godoc
+// @Summary Listar borradores
+// @Description Devuelve los borradores del usuario, el editado más recientemente primero. Solo el propio usuario puede verlos
+// @Tags borradores
+// @Produce json
+// @Param id path int true "ID del usuario"
+// @Param Authorization header string true "Token Bearer del usuario que realiza la consulta"
+// @Param limit query int false "Cantidad máxima de resultados"
+// @Param offset query int false "Desplazamiento para paginar"
+// @Success 200 {array} draft.Draft
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/drafts [get]
+func getDrafts(c *gin.Context, repo *draftRepo.Repository, users *userRepo.Repository) {
+	id, ok := selfOnly(c, users)
+	if !ok {
+		return
+	}
+	limit, offset, ok := pagination(c)
+	if !ok {
+		return
+	}
+
+	drafts, err := repo.GetByUser(id, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener borradores"})
+		return
+	}
+	c.JSON(http.StatusOK, drafts)
+}
+
+// createDraft godoc
+// @Summary Crear un borrador
+// @Description Guarda un tweet sin publicar
+// @Tags borradores
+// @Accept json
+// @Produce json
+// @Param id path int true "ID del usuario"
+// @Param Authorization header string true "Token Bearer del usuario que realiza la consulta"
+// @Param draft body draft.DraftInput true "Texto del borrador"
+// @Success 201 {object} draft.Draft
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/drafts [post]
+func createDraft(c *gin.Context, repo *draftRepo.Repository, users *userRepo.Repository) {
+	id, ok := selfOnly(c, users)
+	if !ok {
+		return
+	}
+
+	var input draft.DraftInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos"})
+		return
+	}
+
+	d, err := repo.Create(id, input.Message)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo guardar el borrador"})
+		return
+	}
+	c.JSON(http.StatusCreated, d)
+}
+
+// updateDraft godoc
+// @Summary Actualizar un borrador
+// @Description Reemplaza el texto de un borrador
+// @Tags borradores
+// @Accept json
+// @Produce json
+// @Param id path int true "ID del usuario"
+// @Param draft_id path int true "ID del borrador"
+// @Param Authorization header string true "Token Bearer del usuario que realiza la consulta"
+// @Param draft body draft.DraftInput true "Texto del borrador"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/drafts/{draft_id} [put]
+func updateDraft(c *gin.Context, repo *draftRepo.Repository, users *userRepo.Repository) {
+	d, ok := ownDraft(c, repo, users)
+	if !ok {
+		return
+	}
+
+	var input draft.DraftInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos"})
+		return
+	}
+
+	if err := repo.Update(d.Id, input.Message); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo actualizar el borrador"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Borrador actualizado"})
+}
+
+// deleteDraft godoc
+// @Summary Eliminar un borrador
+// @Description Descarta un borrador
+// @Tags borradores
+// @Produce json
+// @Param id path int true "ID del usuario"
+// @Param draft_id path int true "ID del borrador"
+// @Param Authorization header string true "Token Bearer del usuario que realiza la consulta"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/drafts/{draft_id} [delete]
+func deleteDraft(c *gin.Context, repo *draftRepo.Repository, users *userRepo.Repository) {
+	d, ok := ownDraft(c, repo, users)
+	if !ok {
+		return
+	}
+
+	if err := repo.Delete(d.Id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo eliminar el borrador"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Borrador eliminado"})
+}
+
+// publishDraft godoc
+// @Summary Publicar un borrador
+// @Description Convierte el borrador en un tweet con las mismas validaciones que la creación de tweets y lo elimina de los borradores
+// @Tags borradores
+// @Produce json
+// @Param id path int true "ID del usuario"
+// @Param draft_id path int true "ID del borrador"
+// @Param Authorization header string true "Token Bearer del usuario que realiza la consulta"
+// @Success 201 {object} map[string]string
+// @Success 202 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/drafts/{draft_id}/publish [post]
+func publishDraft(c *gin.Context, repo *draftRepo.Repository, tweets *tweetRepo.Repository, users *userRepo.Repository, audits *auditRepo.Repository) {
+	d, ok := ownDraft(c, repo, users)
+	if !ok {
+		return
+	}
+	if !validMessage(c, d.Message) {
+		return
+	}
+
+	id, decision, err := tweets.PublishDraft(d.Id)
+	if errors.Is(err, tweetRepo.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Borrador no encontrado"})
+		return
+	}
+	if errors.Is(err, tweetRepo.ErrRejected) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "El mensaje infringe las reglas de contenido"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo publicar el borrador"})
+		return
+	}
+	published := gin.H{
+		"author_id": d.UserID,
+		"message":   d.Message,
+		"draft_id":  d.Id,
+		"hidden":    decision.Action == filter.ActionHold,
+	}
+	if len(decision.Labels) > 0 {
+		published["labels"] = decision.Labels
+	}
+	recordAudit(c, audits, audit.ActionTweetPublish, audit.TargetTweet, int(id), nil, published)
+	filteredResponse(c, http.StatusCreated, "Tweet creado", decision)
+}
+
+// ownDraft resolves the draft in the path, checking it belongs to the
+// requesting user. Other users' drafts are reported as not found.
+func ownDraft(c *gin.Context, repo *draftRepo.Repository, users *userRepo.Repository) (*draft.Draft, bool) {
+	userID, ok := selfOnly(c, users)
+	if !ok {
+		return nil, false
+	}
+	draftID, err := strconv.Atoi(c.Param("draft_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return nil, false
+	}
+
+	d, err := repo.GetByID(draftID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al buscar el borrador"})
+		return nil, false
+	}
+	if d == nil || d.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Borrador no encontrado"})
+		return nil, false
+	}
+	return d, true
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"ualabackend/archive"
+	export "ualabackend/entities/export"
+	exportRepo "ualabackend/repositories/export"
+	userRepo "ualabackend/repositories/user"
+
+	"github.com/gin-gonic/gin"
+)
+
+func exportRoutes(router *gin.Engine, archiver *archive.Service, jobs *exportRepo.Repository, users *userRepo.Repository) {
+	router.POST("/users/:id/export", func(c *gin.Context) { requestExport(c, archiver, users) })
+	router.GET("/users/:id/export/:job_id", func(c *gin.Context) { getExport(c, jobs, users) })
+}
+
+// requestExport godoc
+// @Summary Solicitar una exportación de datos
+// @Description Genera en segundo plano un ZIP con el perfil, los tweets, los seguidos y los seguidores del usuario en JSON y CSV. Solo el propio usuario puede solicitarlo, y no mientras tenga otra exportación pendiente
+// @Tags usuarios
+// @Produce json
+// @Param id path int true "ID del usuario"
+// @Param Authorization header string true "Token Bearer del usuario que realiza la consulta"
+// @Success 202 {object} export.Job
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/export [post]
+func requestExport(c *gin.Context, archiver *archive.Service, users *userRepo.Repository) {
+	id, ok := selfOnly(c, users)
+	if !ok {
+		return
+	}
+
+	job, err := archiver.Start(id)
+	if errors.Is(err, exportRepo.ErrInProgress) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Ya hay una exportación en curso"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al iniciar la exportación"})
+		return
+	}
+	c.JSON(http.StatusAccepted, job)
+}
+
+// getExport godoc
+// @Summary Consultar o descargar una exportación
+// @Description Devuelve el estado de la exportación o, si ya terminó, el archivo ZIP. El archivo solo puede descargarse durante un tiempo limitado
+// @Tags usuarios
+// @Produce json
+// @Produce application/zip
+// @Param id path int true "ID del usuario"
+// @Param job_id path string true "ID de la exportación"
+// @Param Authorization header string true "Token Bearer del usuario que realiza la consulta"
+// @Success 200 {object} export.Job
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 410 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/export/{job_id} [get]
+func getExport(c *gin.Context, jobs *exportRepo.Repository, users *userRepo.Repository) {
+	id, ok := selfOnly(c, users)
+	if !ok {
+		return
+	}
+
+	job, err := jobs.GetByID(c.Param("job_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la exportación"})
+		return
+	}
+	if job == nil || job.UserID != id {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Exportación no encontrada"})
+		return
+	}
+
+	switch {
+	case job.Status == export.StatusExpired,
+		job.Status == export.StatusDone && job.ExpiresAt != nil && time.Now().After(*job.ExpiresAt):
+		c.JSON(http.StatusGone, gin.H{"error": "La exportación expiró"})
+	case job.Status == export.StatusDone:
+		c.FileAttachment(job.FilePath, fmt.Sprintf("export-%d-%s.zip", id, job.CreatedAt.Format("20060102")))
+	default:
+		c.JSON(http.StatusOK, job)
+	}
+}
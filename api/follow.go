@@ -1,25 +1,36 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
+	"ualabackend/entities/audit"
 	follow "ualabackend/entities/follow"
+	auditRepo "ualabackend/repositories/audit"
 	followRepo "ualabackend/repositories/follow"
 
 	"github.com/gin-gonic/gin"
 )
 
-func followRoutes(router *gin.Engine, repo *followRepo.Repository) {
+func followRoutes(router *gin.Engine, repo *followRepo.Repository, audits *auditRepo.Repository) {
 	follows := router.Group("/follows")
 	{
 		follows.GET("/", func(c *gin.Context) { getAllFollows(c, repo) })
-		follows.POST("/", func(c *gin.Context) { createFollow(c, repo) })
+		follows.POST("/", func(c *gin.Context) { createFollow(c, repo, audits) })
 		follows.GET("/:follower_id", func(c *gin.Context) { getFollowedByFollowerID(c, repo) })
 		follows.GET("/:follower_id/:followed_id", func(c *gin.Context) { getFollowByID(c, repo) })
-		follows.DELETE("/:follower_id/:followed_id", func(c *gin.Context) { deleteFollow(c, repo) })
+		follows.DELETE("/:follower_id/:followed_id", requireOwner(pathOwner("follower_id")), func(c *gin.Context) { deleteFollow(c, repo, audits) })
 
 	}
+
+	owner := requireOwner(pathOwner("id"))
+	requests := router.Group("/users")
+	{
+		requests.GET("/:id/follow-requests", owner, func(c *gin.Context) { getFollowRequests(c, repo) })
+		requests.POST("/:id/follow-requests/:requester_id/approve", owner, func(c *gin.Context) { approveFollowRequest(c, repo, audits) })
+		requests.DELETE("/:id/follow-requests/:requester_id", owner, func(c *gin.Context) { rejectFollowRequest(c, repo, audits) })
+	}
 }
 
 // getAllFollows godoc
@@ -40,27 +51,51 @@ func getAllFollows(c *gin.Context, repo *followRepo.Repository) {
 
 // createFollow godoc
 // @Summary Crear un nuevo follow
-// @Description Crea un follow entre usuarios
+// @Description Crea un follow entre usuarios. Si la cuenta seguida es protegida se crea una solicitud pendiente
 // @Tags follows
 // @Accept json
 // @Produce json
+// @Param Authorization header string true "Token Bearer del seguidor"
 // @Param follow body follow.FollowInput true "Datos del follow"
 // @Success 201 {object} map[string]interface{}
+// @Success 202 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /follows/ [post]
-func createFollow(c *gin.Context, repo *followRepo.Repository) {
+func createFollow(c *gin.Context, repo *followRepo.Repository, audits *auditRepo.Repository) {
 	var payload follow.FollowInput
 	if err := c.ShouldBindJSON(&payload); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos"})
 		return
 	}
+	viewer, ok := requireViewer(c)
+	if !ok {
+		return
+	}
+	if payload.FollowerID != viewer {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Solo se puede seguir como el usuario autenticado"})
+		return
+	}
 
-	err := repo.Create(payload.FollowerID, payload.FollowedID)
+	pending, err := repo.Request(payload.FollowerID, payload.FollowedID)
+	if errors.Is(err, followRepo.ErrBlocked) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "No se puede seguir a este usuario"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo crear el follow"})
 		return
 	}
+	if pending {
+		recordAudit(c, audits, audit.ActionFollowRequest, audit.TargetUser, payload.FollowedID, nil,
+			followState(payload.FollowerID, payload.FollowedID, followPending))
+		c.JSON(http.StatusAccepted, gin.H{"message": "Solicitud de follow enviada"})
+		return
+	}
+	recordAudit(c, audits, audit.ActionFollowCreate, audit.TargetUser, payload.FollowedID, nil,
+		followState(payload.FollowerID, payload.FollowedID, followActive))
 
 	c.JSON(http.StatusCreated, gin.H{"message": "Follow creado"})
 }
@@ -102,9 +137,12 @@ func getFollowByID(c *gin.Context, repo *followRepo.Repository) {
 // @Produce json
 // @Param follower_id path int true "ID del seguidor"
 // @Param followed_id path int true "ID del seguido"
+// @Param Authorization header string true "Token Bearer del seguidor"
 // @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Router /follows/{follower_id}/{followed_id} [delete]
-func deleteFollow(c *gin.Context, repo *followRepo.Repository) {
+func deleteFollow(c *gin.Context, repo *followRepo.Repository, audits *auditRepo.Repository) {
 	followerID, err1 := strconv.Atoi(c.Param("follower_id"))
 	followedID, err2 := strconv.Atoi(c.Param("followed_id"))
 	if err1 != nil || err2 != nil {
@@ -112,11 +150,15 @@ func deleteFollow(c *gin.Context, repo *followRepo.Repository) {
 		return
 	}
 
-	err := repo.Delete(followerID, followedID)
+	found, err := repo.Delete(followerID, followedID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar follow"})
 		return
 	}
+	if found {
+		recordAudit(c, audits, audit.ActionFollowDelete, audit.TargetUser, followedID,
+			followState(followerID, followedID, followActive), nil)
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Follow eliminado"})
 }
@@ -146,3 +188,121 @@ func getFollowedByFollowerID(c *gin.Context, repo *followRepo.Repository) {
 
 	c.JSON(http.StatusOK, follows)
 }
+
+// getFollowRequests godoc
+// @Summary Listar solicitudes de follow pendientes
+// @Description Devuelve las solicitudes pendientes recibidas por una cuenta protegida, de la más antigua a la más reciente
+// @Tags follows
+// @Produce json
+// @Param id path int true "ID del usuario"
+// @Param Authorization header string true "Token Bearer del usuario"
+// @Param limit query int false "Cantidad máxima de resultados"
+// @Param offset query int false "Desplazamiento para paginar"
+// @Success 200 {array} follow.FollowRequest
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/follow-requests [get]
+func getFollowRequests(c *gin.Context, repo *followRepo.Repository) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	limit, offset, ok := pagination(c)
+	if !ok {
+		return
+	}
+
+	requests, err := repo.GetRequests(id, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener solicitudes"})
+		return
+	}
+	c.JSON(http.StatusOK, requests)
+}
+
+// approveFollowRequest godoc
+// @Summary Aprobar una solicitud de follow
+// @Description Convierte la solicitud pendiente en un follow
+// @Tags follows
+// @Produce json
+// @Param id path int true "ID del usuario protegido"
+// @Param requester_id path int true "ID del solicitante"
+// @Param Authorization header string true "Token Bearer del usuario"
+// @Success 201 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/follow-requests/{requester_id}/approve [post]
+func approveFollowRequest(c *gin.Context, repo *followRepo.Repository, audits *auditRepo.Repository) {
+	id, err1 := strconv.Atoi(c.Param("id"))
+	requesterID, err2 := strconv.Atoi(c.Param("requester_id"))
+	if err1 != nil || err2 != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "IDs inválidos"})
+		return
+	}
+
+	found, err := repo.ApproveRequest(id, requesterID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo aprobar la solicitud"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Solicitud no encontrada"})
+		return
+	}
+	recordAudit(c, audits, audit.ActionFollowApprove, audit.TargetUser, id,
+		followState(requesterID, id, followPending), followState(requesterID, id, followActive))
+	c.JSON(http.StatusCreated, gin.H{"message": "Solicitud aprobada"})
+}
+
+// rejectFollowRequest godoc
+// @Summary Rechazar una solicitud de follow
+// @Description Descarta la solicitud pendiente
+// @Tags follows
+// @Produce json
+// @Param id path int true "ID del usuario protegido"
+// @Param requester_id path int true "ID del solicitante"
+// @Param Authorization header string true "Token Bearer del usuario"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/follow-requests/{requester_id} [delete]
+func rejectFollowRequest(c *gin.Context, repo *followRepo.Repository, audits *auditRepo.Repository) {
+	id, err1 := strconv.Atoi(c.Param("id"))
+	requesterID, err2 := strconv.Atoi(c.Param("requester_id"))
+	if err1 != nil || err2 != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "IDs inválidos"})
+		return
+	}
+
+	found, err := repo.RejectRequest(id, requesterID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo rechazar la solicitud"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Solicitud no encontrada"})
+		return
+	}
+	recordAudit(c, audits, audit.ActionFollowReject, audit.TargetUser, id,
+		followState(requesterID, id, followPending), nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Solicitud rechazada"})
+}
+
+const (
+	followActive  = "following"
+	followPending = "pending"
+)
+
+// followState describes a follow, or a pending request, for the audit log.
+func followState(followerID, followedID int, status string) gin.H {
+	return gin.H{"follower_id": followerID, "followed_id": followedID, "status": status}
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"ualabackend/entities/audit"
+	"ualabackend/entities/user"
+	"ualabackend/importer"
+	auditRepo "ualabackend/repositories/audit"
+	tweetRepo "ualabackend/repositories/tweet"
+	userRepo "ualabackend/repositories/user"
+	"ualabackend/search"
+
+	"github.com/gin-gonic/gin"
+)
+
+func importRoutes(router *gin.Engine, imp *importer.Importer, tweets *tweetRepo.Repository, users *userRepo.Repository, audits *auditRepo.Repository) {
+	router.POST("/import/", requireRole(users, user.RoleAdmin), func(c *gin.Context) { bulkImport(c, imp, tweets, users, audits) })
+}
+
+// bulkImport godoc
+// @Summary Importar usuarios, follows y tweets en lote
+// @Description Importa un archivo NDJSON o CSV con filas de tipo user, follow o tweet. Solo para administradores. Los usuarios se referencian por su external_id. Las filas inválidas se informan sin interrumpir la importación y los contadores y timelines se reconstruyen al final. Cada fila importada queda en el registro de auditoría
+// @Tags importación
+// @Accept plain
+// @Produce json
+// @Param Authorization header string true "Token Bearer del administrador"
+// @Param format query string false "Formato del archivo: ndjson o csv. Por defecto se deduce del Content-Type" Enums(ndjson, csv)
+// @Param file body string true "Contenido del archivo"
+// @Success 200 {object} importer.Report
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]interface{}
+// @Router /import/ [post]
+func bulkImport(c *gin.Context, imp *importer.Importer, tweets *tweetRepo.Repository, users *userRepo.Repository, audits *auditRepo.Repository) {
+	format := c.Query("format")
+	if format == "" {
+		format = importer.FormatNDJSON
+		if strings.HasPrefix(c.ContentType(), "text/csv") {
+			format = importer.FormatCSV
+		}
+	}
+	if format != importer.FormatNDJSON && format != importer.FormatCSV {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Formato inválido"})
+		return
+	}
+
+	report, err := imp.Run(c.Request.Body, format)
+	if report != nil {
+		// Batches committed before a failure stay imported, so they are
+		// audited either way.
+		auditImport(c, audits, report.Imported)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al importar", "report": report})
+		return
+	}
+
+	if err := reindex(tweets, users); err != nil {
+		log.Printf("⚠️ Could not reindex after import: %v", err)
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// auditImport records an audit entry for every row an import inserted.
+func auditImport(c *gin.Context, audits *auditRepo.Repository, imported []importer.Imported) {
+	for _, row := range imported {
+		switch row.Type {
+		case importer.TypeUser:
+			recordAudit(c, audits, audit.ActionUserImport, audit.TargetUser, row.ID, nil,
+				gin.H{"name": row.Record.Name, "external_id": row.Record.ExternalID})
+		case importer.TypeFollow:
+			recordAudit(c, audits, audit.ActionFollowImport, audit.TargetUser, row.ID, nil,
+				followState(row.FollowerID, row.ID, followActive))
+		case importer.TypeTweet:
+			created := gin.H{"author_id": row.AuthorID, "message": row.Record.Message}
+			if row.Record.ExternalID != "" {
+				created["external_id"] = row.Record.ExternalID
+			}
+			recordAudit(c, audits, audit.ActionTweetImport, audit.TargetTweet, row.ID, nil, created)
+		}
+	}
+}
+
+// reindex rebuilds the in-memory search indexes from the database.
+func reindex(tweets *tweetRepo.Repository, users *userRepo.Repository) error {
+	docs, err := tweets.Documents()
+	if err != nil {
+		return err
+	}
+	if err := search.Rebuild(tweets.Index, docs); err != nil {
+		return err
+	}
+	return users.IndexNames()
+}
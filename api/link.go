@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+
+	linkRepo "ualabackend/repositories/link"
+
+	"github.com/gin-gonic/gin"
+)
+
+func linkRoutes(router *gin.Engine, repo *linkRepo.Repository) {
+	router.GET("/l/:code", func(c *gin.Context) { followLink(c, repo) })
+}
+
+// followLink godoc
+// @Summary Seguir un link acortado
+// @Description Redirige a la URL original del link acortado y cuenta el click
+// @Tags links
+// @Param code path string true "Código del link"
+// @Success 302
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /l/{code} [get]
+func followLink(c *gin.Context, repo *linkRepo.Repository) {
+	url, err := repo.Follow(c.Param("code"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al buscar el link"})
+		return
+	}
+	if url == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Link no encontrado"})
+		return
+	}
+	c.Redirect(http.StatusFound, url)
+}
@@ -0,0 +1,445 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	list "ualabackend/entities/list"
+	listRepo "ualabackend/repositories/list"
+	tweetRepo "ualabackend/repositories/tweet"
+	userRepo "ualabackend/repositories/user"
+
+	"github.com/gin-gonic/gin"
+)
+
+func listRoutes(router *gin.Engine, repo *listRepo.Repository, tweets *tweetRepo.Repository, users *userRepo.Repository) {
+	router.GET("/users/:id/lists", func(c *gin.Context) { getUserLists(c, repo, users) })
+	router.POST("/users/:id/lists", func(c *gin.Context) { createList(c, repo, users) })
+
+	lists := router.Group("/lists")
+	{
+		lists.GET("/:id", func(c *gin.Context) { getList(c, repo) })
+		lists.PUT("/:id", func(c *gin.Context) { updateList(c, repo) })
+		lists.DELETE("/:id", func(c *gin.Context) { deleteList(c, repo) })
+		lists.GET("/:id/members", func(c *gin.Context) { getListMembers(c, repo) })
+		lists.POST("/:id/members", func(c *gin.Context) { addListMember(c, repo, users) })
+		lists.DELETE("/:id/members/:user_id", func(c *gin.Context) { removeListMember(c, repo) })
+		lists.GET("/:id/subscribers", func(c *gin.Context) { getListSubscribers(c, repo) })
+		lists.POST("/:id/subscribers", func(c *gin.Context) { subscribeList(c, repo) })
+		lists.DELETE("/:id/subscribers", func(c *gin.Context) { unsubscribeList(c, repo) })
+		lists.GET("/:id/timeline", func(c *gin.Context) { getListTimeline(c, repo, tweets) })
+	}
+}
+
+// getUserLists godoc
+// @Summary Listar las listas de un usuario
+// @Description Devuelve las listas creadas por el usuario. Las privadas solo se incluyen para su dueño
+// @Tags listas
+// @Produce json
+// @Param id path int true "ID del usuario"
+// @Param Authorization header string false "Token Bearer del usuario que realiza la consulta"
+// @Success 200 {array} list.List
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/lists [get]
+func getUserLists(c *gin.Context, repo *listRepo.Repository, users *userRepo.Repository) {
+	id, ok := existingUserID(c, users)
+	if !ok {
+		return
+	}
+
+	lists, err := repo.GetByOwner(id, viewerID(c) == id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener listas"})
+		return
+	}
+	c.JSON(http.StatusOK, lists)
+}
+
+// createList godoc
+// @Summary Crear una lista
+// @Description Crea una lista de cuentas para el usuario
+// @Tags listas
+// @Accept json
+// @Produce json
+// @Param id path int true "ID del usuario dueño"
+// @Param list body list.ListInput true "Datos de la lista"
+// @Success 201 {object} list.List
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/lists [post]
+func createList(c *gin.Context, repo *listRepo.Repository, users *userRepo.Repository) {
+	id, ok := existingUserID(c, users)
+	if !ok {
+		return
+	}
+
+	var payload list.ListInput
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos"})
+		return
+	}
+
+	l, err := repo.Create(id, payload)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo crear la lista"})
+		return
+	}
+	c.JSON(http.StatusCreated, l)
+}
+
+// getList godoc
+// @Summary Obtener una lista
+// @Description Devuelve una lista. Las privadas solo son visibles para su dueño
+// @Tags listas
+// @Produce json
+// @Param id path int true "ID de la lista"
+// @Param Authorization header string false "Token Bearer del usuario que realiza la consulta"
+// @Success 200 {object} list.List
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /lists/{id} [get]
+func getList(c *gin.Context, repo *listRepo.Repository) {
+	l, ok := visibleList(c, repo)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, l)
+}
+
+// updateList godoc
+// @Summary Actualizar una lista
+// @Description Cambia el nombre, la descripción o la visibilidad. Al hacerla privada se eliminan los suscriptores
+// @Tags listas
+// @Accept json
+// @Produce json
+// @Param id path int true "ID de la lista"
+// @Param Authorization header string true "Token Bearer del dueño de la lista"
+// @Param list body list.ListInput true "Datos de la lista"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /lists/{id} [put]
+func updateList(c *gin.Context, repo *listRepo.Repository) {
+	l, ok := ownedList(c, repo)
+	if !ok {
+		return
+	}
+
+	var payload list.ListInput
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos"})
+		return
+	}
+
+	if err := repo.Update(l.Id, payload); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo actualizar la lista"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Lista actualizada"})
+}
+
+// deleteList godoc
+// @Summary Eliminar una lista
+// @Description Elimina la lista junto con sus miembros y suscriptores
+// @Tags listas
+// @Produce json
+// @Param id path int true "ID de la lista"
+// @Param Authorization header string true "Token Bearer del dueño de la lista"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /lists/{id} [delete]
+func deleteList(c *gin.Context, repo *listRepo.Repository) {
+	l, ok := ownedList(c, repo)
+	if !ok {
+		return
+	}
+
+	if err := repo.Delete(l.Id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo eliminar la lista"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Lista eliminada"})
+}
+
+// getListMembers godoc
+// @Summary Listar miembros de una lista
+// @Description Devuelve las cuentas que forman parte de la lista
+// @Tags listas
+// @Produce json
+// @Param id path int true "ID de la lista"
+// @Param Authorization header string false "Token Bearer del usuario que realiza la consulta"
+// @Param limit query int false "Cantidad máxima de resultados"
+// @Param offset query int false "Desplazamiento para paginar"
+// @Success 200 {array} user.UserSummary
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /lists/{id}/members [get]
+func getListMembers(c *gin.Context, repo *listRepo.Repository) {
+	l, ok := visibleList(c, repo)
+	if !ok {
+		return
+	}
+	limit, offset, ok := pagination(c)
+	if !ok {
+		return
+	}
+
+	members, err := repo.GetMembers(l.Id, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener miembros"})
+		return
+	}
+	c.JSON(http.StatusOK, members)
+}
+
+// addListMember godoc
+// @Summary Agregar un miembro a una lista
+// @Description Agrega una cuenta a la lista sin necesidad de seguirla
+// @Tags listas
+// @Accept json
+// @Produce json
+// @Param id path int true "ID de la lista"
+// @Param Authorization header string true "Token Bearer del dueño de la lista"
+// @Param member body list.MemberInput true "Usuario a agregar"
+// @Success 201 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /lists/{id}/members [post]
+func addListMember(c *gin.Context, repo *listRepo.Repository, users *userRepo.Repository) {
+	l, ok := ownedList(c, repo)
+	if !ok {
+		return
+	}
+
+	var payload list.MemberInput
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos"})
+		return
+	}
+
+	u, err := users.GetByID(payload.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al buscar usuario"})
+		return
+	}
+	if u == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+		return
+	}
+
+	if err := repo.AddMember(l.Id, payload.UserID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo agregar el miembro"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Miembro agregado"})
+}
+
+// removeListMember godoc
+// @Summary Quitar un miembro de una lista
+// @Description Quita una cuenta de la lista
+// @Tags listas
+// @Produce json
+// @Param id path int true "ID de la lista"
+// @Param user_id path int true "ID del miembro"
+// @Param Authorization header string true "Token Bearer del dueño de la lista"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /lists/{id}/members/{user_id} [delete]
+func removeListMember(c *gin.Context, repo *listRepo.Repository) {
+	l, ok := ownedList(c, repo)
+	if !ok {
+		return
+	}
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	if err := repo.RemoveMember(l.Id, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo quitar el miembro"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Miembro eliminado"})
+}
+
+// getListSubscribers godoc
+// @Summary Listar suscriptores de una lista
+// @Description Devuelve los usuarios suscriptos a la lista
+// @Tags listas
+// @Produce json
+// @Param id path int true "ID de la lista"
+// @Param Authorization header string false "Token Bearer del usuario que realiza la consulta"
+// @Param limit query int false "Cantidad máxima de resultados"
+// @Param offset query int false "Desplazamiento para paginar"
+// @Success 200 {array} user.UserSummary
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /lists/{id}/subscribers [get]
+func getListSubscribers(c *gin.Context, repo *listRepo.Repository) {
+	l, ok := visibleList(c, repo)
+	if !ok {
+		return
+	}
+	limit, offset, ok := pagination(c)
+	if !ok {
+		return
+	}
+
+	subscribers, err := repo.GetSubscribers(l.Id, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener suscriptores"})
+		return
+	}
+	c.JSON(http.StatusOK, subscribers)
+}
+
+// subscribeList godoc
+// @Summary Suscribirse a una lista
+// @Description Suscribe al usuario a una lista visible para él
+// @Tags listas
+// @Produce json
+// @Param id path int true "ID de la lista"
+// @Param Authorization header string true "Token Bearer del usuario"
+// @Success 201 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /lists/{id}/subscribers [post]
+func subscribeList(c *gin.Context, repo *listRepo.Repository) {
+	userID, ok := requireViewer(c)
+	if !ok {
+		return
+	}
+	l, ok := visibleList(c, repo)
+	if !ok {
+		return
+	}
+
+	if err := repo.Subscribe(l.Id, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo suscribir a la lista"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Suscripción creada"})
+}
+
+// unsubscribeList godoc
+// @Summary Desuscribirse de una lista
+// @Description Elimina la suscripción del usuario a la lista
+// @Tags listas
+// @Produce json
+// @Param id path int true "ID de la lista"
+// @Param Authorization header string true "Token Bearer del usuario"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /lists/{id}/subscribers [delete]
+func unsubscribeList(c *gin.Context, repo *listRepo.Repository) {
+	userID, ok := requireViewer(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	if err := repo.Unsubscribe(id, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo eliminar la suscripción"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Suscripción eliminada"})
+}
+
+// getListTimeline godoc
+// @Summary Timeline de una lista
+// @Description Devuelve los tweets de los miembros de la lista, del más reciente al más antiguo
+// @Tags listas
+// @Produce json
+// @Param id path int true "ID de la lista"
+// @Param Authorization header string false "Token Bearer del usuario que realiza la consulta"
+// @Param limit query int false "Cantidad máxima de resultados"
+// @Param offset query int false "Desplazamiento para paginar"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /lists/{id}/timeline [get]
+func getListTimeline(c *gin.Context, repo *listRepo.Repository, tweets *tweetRepo.Repository) {
+	l, ok := visibleList(c, repo)
+	if !ok {
+		return
+	}
+	limit, offset, ok := pagination(c)
+	if !ok {
+		return
+	}
+
+	timeline, err := tweets.ListTimeline(l.Id, viewerID(c), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo obtener el timeline"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tweets": timeline})
+}
+
+// visibleList loads the :id list, answering 404 when it does not exist or is
+// private and the viewer is not its owner.
+func visibleList(c *gin.Context, repo *listRepo.Repository) (*list.List, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return nil, false
+	}
+
+	l, err := repo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al buscar la lista"})
+		return nil, false
+	}
+	if l == nil || (l.Private && l.OwnerID != viewerID(c)) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Lista no encontrada"})
+		return nil, false
+	}
+	return l, true
+}
+
+// ownedList is like visibleList but also requires the viewer to be the owner.
+func ownedList(c *gin.Context, repo *listRepo.Repository) (*list.List, bool) {
+	userID, ok := requireViewer(c)
+	if !ok {
+		return nil, false
+	}
+	l, ok := visibleList(c, repo)
+	if !ok {
+		return nil, false
+	}
+	if l.OwnerID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Solo el dueño puede modificar la lista"})
+		return nil, false
+	}
+	return l, true
+}
@@ -0,0 +1,341 @@
+package api
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	media "ualabackend/entities/media"
+	"ualabackend/mediafile"
+	mediaRepo "ualabackend/repositories/media"
+	tweetRepo "ualabackend/repositories/tweet"
+	"ualabackend/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxAltTextLength = 1000
+
+	// unattachedMediaTTL is how long an upload may wait for a tweet before
+	// the cleanup job deletes it.
+	unattachedMediaTTL = 24 * time.Hour
+)
+
+func mediaRoutes(router *gin.Engine, repo *mediaRepo.Repository, blobs storage.Blobs, tweets *tweetRepo.Repository) {
+	m := router.Group("/media")
+	{
+		m.POST("/", func(c *gin.Context) { uploadMedia(c, repo, blobs) })
+		m.GET("/:id", func(c *gin.Context) { getMedia(c, repo, tweets) })
+		m.PUT("/:id", func(c *gin.Context) { updateAltText(c, repo) })
+		m.GET("/:id/file", func(c *gin.Context) { serveMedia(c, repo, blobs, tweets, false) })
+		m.GET("/:id/thumbnail", func(c *gin.Context) { serveMedia(c, repo, blobs, tweets, true) })
+	}
+}
+
+// uploadMedia godoc
+// @Summary Subir un archivo multimedia
+// @Description Sube una imagen (JPEG, PNG o GIF, hasta 5 MB y 40 megapíxeles) o un video (MP4 o WebM, hasta 50 MB) para adjuntarlo a un tweet. El tipo se detecta a partir del contenido. Las imágenes reciben una miniatura. Los archivos que no se adjuntan a un tweet en 24 horas se eliminan
+// @Tags media
+// @Accept multipart/form-data
+// @Produce json
+// @Param Authorization header string true "Token Bearer del usuario que sube el archivo"
+// @Param file formData file true "Archivo"
+// @Param alt_text formData string false "Texto alternativo"
+// @Success 201 {object} media.Media
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 415 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /media/ [post]
+func uploadMedia(c *gin.Context, repo *mediaRepo.Repository, blobs storage.Blobs) {
+	ownerID, ok := requireViewer(c)
+	if !ok {
+		return
+	}
+
+	// Leave room for the multipart envelope and the other fields.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, mediafile.MaxVideoSize+1<<20)
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "El archivo supera el tamaño máximo"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El archivo es requerido"})
+		return
+	}
+	defer file.Close()
+
+	altText := strings.TrimSpace(c.PostForm("alt_text"))
+	if utf8.RuneCountInString(altText) > maxAltTextLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El texto alternativo es demasiado largo"})
+		return
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No se pudo leer el archivo"})
+		return
+	}
+	head = head[:n]
+
+	mimeType, kind, err := mediafile.Sniff(head)
+	if err != nil {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Tipo de archivo no soportado"})
+		return
+	}
+	if header.Size > mediafile.MaxSize(kind) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "El archivo supera el tamaño máximo"})
+		return
+	}
+
+	key, err := newBlobKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo guardar el archivo"})
+		return
+	}
+	m := media.Media{
+		OwnerID:  ownerID,
+		Kind:     kind,
+		MimeType: mimeType,
+		Size:     header.Size,
+		AltText:  altText,
+		BlobKey:  key,
+	}
+
+	content := io.MultiReader(bytes.NewReader(head), file)
+	if kind == mediafile.KindImage {
+		data, err := io.ReadAll(content)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No se pudo leer el archivo"})
+			return
+		}
+		width, height, thumb, err := mediafile.Thumbnail(data)
+		if errors.Is(err, mediafile.ErrTooManyPixels) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "La imagen supera las dimensiones máximas"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Imagen inválida"})
+			return
+		}
+		m.Width, m.Height = width, height
+		m.ThumbnailKey = key + "-thumb.jpg"
+		if err := blobs.Put(m.ThumbnailKey, bytes.NewReader(thumb)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo guardar el archivo"})
+			return
+		}
+		content = bytes.NewReader(data)
+	}
+
+	if err := blobs.Put(m.BlobKey, content); err != nil {
+		deleteBlobs(blobs, m)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo guardar el archivo"})
+		return
+	}
+
+	created, err := repo.Create(m)
+	if err != nil {
+		deleteBlobs(blobs, m)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo guardar el archivo"})
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+// getMedia godoc
+// @Summary Obtener un archivo multimedia
+// @Description Devuelve los datos de un archivo multimedia. Los archivos sin adjuntar solo son visibles para su dueño y los adjuntos siguen la visibilidad de su tweet
+// @Tags media
+// @Produce json
+// @Param id path int true "ID del archivo"
+// @Param Authorization header string false "Token Bearer del usuario que realiza la consulta"
+// @Success 200 {object} media.Media
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /media/{id} [get]
+func getMedia(c *gin.Context, repo *mediaRepo.Repository, tweets *tweetRepo.Repository) {
+	m, ok := visibleMedia(c, repo, tweets)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, m)
+}
+
+// updateAltText godoc
+// @Summary Actualizar el texto alternativo
+// @Description Cambia el texto alternativo de un archivo multimedia propio
+// @Tags media
+// @Accept json
+// @Produce json
+// @Param id path int true "ID del archivo"
+// @Param Authorization header string true "Token Bearer del dueño del archivo"
+// @Param alt_text body media.AltTextInput true "Texto alternativo"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /media/{id} [put]
+func updateAltText(c *gin.Context, repo *mediaRepo.Repository) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	ownerID, ok := requireViewer(c)
+	if !ok {
+		return
+	}
+
+	var input media.AltTextInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos"})
+		return
+	}
+	altText := strings.TrimSpace(input.AltText)
+	if utf8.RuneCountInString(altText) > maxAltTextLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El texto alternativo es demasiado largo"})
+		return
+	}
+
+	m, err := repo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al buscar el archivo"})
+		return
+	}
+	if m == nil || m.OwnerID != ownerID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Archivo no encontrado"})
+		return
+	}
+
+	if err := repo.SetAltText(id, altText); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo actualizar el archivo"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Texto alternativo actualizado"})
+}
+
+// serveMedia godoc
+// @Summary Descargar un archivo multimedia
+// @Description Devuelve el contenido del archivo o de su miniatura, con las mismas reglas de visibilidad que sus datos
+// @Tags media
+// @Produce octet-stream
+// @Param id path int true "ID del archivo"
+// @Param Authorization header string false "Token Bearer del usuario que realiza la consulta"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /media/{id}/file [get]
+// @Router /media/{id}/thumbnail [get]
+func serveMedia(c *gin.Context, repo *mediaRepo.Repository, blobs storage.Blobs, tweets *tweetRepo.Repository, thumbnail bool) {
+	m, ok := visibleMedia(c, repo, tweets)
+	if !ok {
+		return
+	}
+
+	key, contentType, size := m.BlobKey, m.MimeType, m.Size
+	if thumbnail {
+		if m.ThumbnailKey == "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "El archivo no tiene miniatura"})
+			return
+		}
+		key, contentType, size = m.ThumbnailKey, "image/jpeg", -1
+	}
+
+	content, err := blobs.Open(key)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo leer el archivo"})
+		return
+	}
+	defer content.Close()
+
+	c.DataFromReader(http.StatusOK, size, contentType, content, nil)
+}
+
+// visibleMedia resolves the media in the path if the viewer may see it,
+// writing a 404 otherwise.
+func visibleMedia(c *gin.Context, repo *mediaRepo.Repository, tweets *tweetRepo.Repository) (*media.Media, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return nil, false
+	}
+
+	m, err := repo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al buscar el archivo"})
+		return nil, false
+	}
+
+	visible := false
+	viewer := viewerID(c)
+	if m != nil && m.TweetID != nil {
+		t, err := tweets.GetByID(*m.TweetID, viewer)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al buscar el archivo"})
+			return nil, false
+		}
+		visible = t != nil
+	} else if m != nil {
+		visible = viewer != 0 && m.OwnerID == viewer
+	}
+
+	if !visible {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Archivo no encontrado"})
+		return nil, false
+	}
+	return m, true
+}
+
+// deleteOrphanedMedia removes media nobody can reference anymore, along with
+// their blobs.
+func deleteOrphanedMedia(repo *mediaRepo.Repository, blobs storage.Blobs) error {
+	orphaned, err := repo.Orphaned(time.Now().Add(-unattachedMediaTTL))
+	if err != nil {
+		return err
+	}
+	for _, m := range orphaned {
+		if err := deleteBlobs(blobs, m); err != nil {
+			log.Printf("⚠️ Could not delete blobs of media %d: %v", m.Id, err)
+			continue
+		}
+		if err := repo.Delete(m.Id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deleteBlobs(blobs storage.Blobs, m media.Media) error {
+	if err := blobs.Delete(m.BlobKey); err != nil {
+		return err
+	}
+	if m.ThumbnailKey != "" {
+		return blobs.Delete(m.ThumbnailKey)
+	}
+	return nil
+}
+
+// newBlobKey returns a random, unguessable key for a new upload.
+func newBlobKey() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "media/" + hex.EncodeToString(buf), nil
+}
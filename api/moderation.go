@@ -0,0 +1,410 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"ualabackend/entities/audit"
+	"ualabackend/entities/moderation"
+	"ualabackend/entities/user"
+	auditRepo "ualabackend/repositories/audit"
+	moderationRepo "ualabackend/repositories/moderation"
+	tweetRepo "ualabackend/repositories/tweet"
+	userRepo "ualabackend/repositories/user"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxReportTextLength = 1000
+
+func moderationRoutes(router *gin.Engine, repo *moderationRepo.Repository, tweets *tweetRepo.Repository, users *userRepo.Repository, audits *auditRepo.Repository) {
+	router.POST("/reports", func(c *gin.Context) { createReport(c, repo, tweets, users) })
+
+	m := router.Group("/moderation", requireRole(users, user.RoleModerator))
+	{
+		m.GET("/reports", func(c *gin.Context) { getReportQueue(c, repo) })
+		m.GET("/reports/:id", func(c *gin.Context) { getReport(c, repo) })
+		m.POST("/reports/:id/decision", func(c *gin.Context) { decideReport(c, repo, tweets, users, audits) })
+		m.POST("/actions", func(c *gin.Context) { applyAction(c, repo, tweets, users, audits) })
+		m.GET("/actions", func(c *gin.Context) { getActions(c, repo) })
+	}
+}
+
+// createReport godoc
+// @Summary Denunciar un tweet o un usuario
+// @Description Envía una denuncia sobre un tweet o un usuario a la cola de revisión de los moderadores. Motivos aceptados: spam, abuse, harassment, hate, violence, impersonation, other
+// @Tags moderación
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Token Bearer del usuario que denuncia"
+// @Param report body moderation.ReportInput true "Denuncia"
+// @Success 201 {object} moderation.Report
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /reports [post]
+func createReport(c *gin.Context, repo *moderationRepo.Repository, tweets *tweetRepo.Repository, users *userRepo.Repository) {
+	reporterID, ok := requireViewer(c)
+	if !ok {
+		return
+	}
+
+	var input moderation.ReportInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos"})
+		return
+	}
+	input.Details = strings.TrimSpace(input.Details)
+	if !validReason(input.Reason) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Motivo inválido"})
+		return
+	}
+	if utf8.RuneCountInString(input.Details) > maxReportTextLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El detalle es demasiado largo"})
+		return
+	}
+
+	switch input.TargetType {
+	case moderation.TargetTweet:
+		t, err := tweets.GetByID(input.TargetID, reporterID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al buscar el tweet"})
+			return
+		}
+		if t == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tweet no encontrado"})
+			return
+		}
+		if t.Author_id == reporterID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No se puede denunciar un tweet propio"})
+			return
+		}
+	case moderation.TargetUser:
+		u, err := users.GetByID(input.TargetID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al buscar usuario"})
+			return
+		}
+		if u == nil || u.DeactivatedAt != nil || u.SuspendedAt != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+			return
+		}
+		if u.Id == reporterID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No se puede denunciar al propio usuario"})
+			return
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tipo de denuncia inválido"})
+		return
+	}
+
+	report, err := repo.CreateReport(reporterID, input)
+	if errors.Is(err, moderationRepo.ErrDuplicateReport) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Ya existe una denuncia abierta sobre este contenido"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo crear la denuncia"})
+		return
+	}
+	c.JSON(http.StatusCreated, report)
+}
+
+// getReportQueue godoc
+// @Summary Obtener la cola de denuncias
+// @Description Devuelve las denuncias con el estado indicado, de la más antigua a la más reciente. Solo para moderadores
+// @Tags moderación
+// @Produce json
+// @Param Authorization header string true "Token Bearer del moderador"
+// @Param status query string false "Estado: open (por defecto), resolved o dismissed"
+// @Param limit query int false "Cantidad máxima de resultados"
+// @Param offset query int false "Desplazamiento para paginar"
+// @Success 200 {array} moderation.Report
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /moderation/reports [get]
+func getReportQueue(c *gin.Context, repo *moderationRepo.Repository) {
+	status := c.DefaultQuery("status", moderation.StatusOpen)
+	if status != moderation.StatusOpen && status != moderation.StatusResolved && status != moderation.StatusDismissed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Estado inválido"})
+		return
+	}
+	limit, offset, ok := pagination(c)
+	if !ok {
+		return
+	}
+
+	reports, err := repo.Queue(status, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo obtener la cola de denuncias"})
+		return
+	}
+	c.JSON(http.StatusOK, reports)
+}
+
+// getReport godoc
+// @Summary Obtener una denuncia
+// @Description Devuelve una denuncia. Solo para moderadores
+// @Tags moderación
+// @Produce json
+// @Param id path int true "ID de la denuncia"
+// @Param Authorization header string true "Token Bearer del moderador"
+// @Success 200 {object} moderation.Report
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /moderation/reports/{id} [get]
+func getReport(c *gin.Context, repo *moderationRepo.Repository) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	report, err := repo.GetReport(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al buscar la denuncia"})
+		return
+	}
+	if report == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Denuncia no encontrada"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// decideReport godoc
+// @Summary Resolver una denuncia
+// @Description Aplica una acción sobre el objetivo de la denuncia (hide_tweet para tweets; suspend_user o restrict_reach para usuarios) y resuelve todas las denuncias abiertas sobre él, o la descarta con dismiss. Los tweets retenidos por el filtro de contenido se aprueban con unhide_tweet. La decisión queda registrada en el historial de moderación. Solo para moderadores
+// @Tags moderación
+// @Accept json
+// @Produce json
+// @Param id path int true "ID de la denuncia"
+// @Param Authorization header string true "Token Bearer del moderador"
+// @Param decision body moderation.DecisionInput true "Decisión"
+// @Success 200 {object} moderation.Report
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /moderation/reports/{id}/decision [post]
+func decideReport(c *gin.Context, repo *moderationRepo.Repository, tweets *tweetRepo.Repository, users *userRepo.Repository, audits *auditRepo.Repository) {
+	moderatorID := viewerID(c)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var input moderation.DecisionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos"})
+		return
+	}
+	input.Note = strings.TrimSpace(input.Note)
+	if utf8.RuneCountInString(input.Note) > maxReportTextLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La nota es demasiado larga"})
+		return
+	}
+
+	// The state before the decision is read up front for the audit log;
+	// Decide checks the report and the action again under lock.
+	pending, err := repo.GetReport(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo aplicar la acción"})
+		return
+	}
+	if pending == nil {
+		moderationError(c, moderationRepo.ErrNotFound)
+		return
+	}
+	var before bool
+	if input.Action != moderation.ActionDismiss && moderation.TargetOf(input.Action) == pending.TargetType {
+		if before, err = repo.Enforced(input.Action, pending.TargetID); !moderationError(c, err) {
+			return
+		}
+	}
+
+	report, err := repo.Decide(id, moderatorID, input)
+	if !moderationError(c, err) {
+		return
+	}
+	syncEnforcement(input.Action, report.TargetID, tweets, users)
+	auditEnforcement(c, audits, input.Action, report.TargetID, before)
+	c.JSON(http.StatusOK, report)
+}
+
+// applyAction godoc
+// @Summary Aplicar una acción de moderación
+// @Description Aplica o revierte una acción sin una denuncia de por medio: hide_tweet, unhide_tweet, suspend_user, unsuspend_user, restrict_reach o unrestrict_reach. Queda registrada en el historial de moderación. Solo para moderadores
+// @Tags moderación
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Token Bearer del moderador"
+// @Param action body moderation.ActionInput true "Acción"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /moderation/actions [post]
+func applyAction(c *gin.Context, repo *moderationRepo.Repository, tweets *tweetRepo.Repository, users *userRepo.Repository, audits *auditRepo.Repository) {
+	moderatorID := viewerID(c)
+
+	var input moderation.ActionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos"})
+		return
+	}
+	input.Note = strings.TrimSpace(input.Note)
+	if utf8.RuneCountInString(input.Note) > maxReportTextLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La nota es demasiado larga"})
+		return
+	}
+
+	if moderation.TargetOf(input.Action) != input.TargetType {
+		moderationError(c, moderationRepo.ErrActionMismatch)
+		return
+	}
+	before, err := repo.Enforced(input.Action, input.TargetID)
+	if !moderationError(c, err) {
+		return
+	}
+	if !moderationError(c, repo.Act(moderatorID, input)) {
+		return
+	}
+	syncEnforcement(input.Action, input.TargetID, tweets, users)
+	auditEnforcement(c, audits, input.Action, input.TargetID, before)
+	c.JSON(http.StatusOK, gin.H{"message": "Acción aplicada"})
+}
+
+// getActions godoc
+// @Summary Obtener el historial de moderación
+// @Description Devuelve las decisiones de los moderadores, de la más reciente a la más antigua, opcionalmente sobre un solo objetivo. Solo para moderadores
+// @Tags moderación
+// @Produce json
+// @Param Authorization header string true "Token Bearer del moderador"
+// @Param target_type query string false "Tipo de objetivo: tweet o user"
+// @Param target_id query int false "ID del objetivo, requerido junto con target_type"
+// @Param limit query int false "Cantidad máxima de resultados"
+// @Param offset query int false "Desplazamiento para paginar"
+// @Success 200 {array} moderation.Action
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /moderation/actions [get]
+func getActions(c *gin.Context, repo *moderationRepo.Repository) {
+	limit, offset, ok := pagination(c)
+	if !ok {
+		return
+	}
+
+	targetType := c.Query("target_type")
+	var targetID int
+	if targetType != "" {
+		var err error
+		targetID, err = strconv.Atoi(c.Query("target_id"))
+		if err != nil || (targetType != moderation.TargetTweet && targetType != moderation.TargetUser) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Objetivo inválido"})
+			return
+		}
+	}
+
+	actions, err := repo.Actions(targetType, targetID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo obtener el historial de moderación"})
+		return
+	}
+	c.JSON(http.StatusOK, actions)
+}
+
+// moderationError writes the response for an error returned by the
+// moderation repository. It returns true when there was no error.
+func moderationError(c *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, moderationRepo.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Denuncia no encontrada"})
+	case errors.Is(err, moderationRepo.ErrTargetNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Objetivo no encontrado"})
+	case errors.Is(err, moderationRepo.ErrAlreadyDecided):
+		c.JSON(http.StatusConflict, gin.H{"error": "La denuncia ya fue resuelta"})
+	case errors.Is(err, moderationRepo.ErrActionMismatch):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Acción inválida para este objetivo"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo aplicar la acción"})
+	}
+	return false
+}
+
+// enforcementAudits maps each enforcement action to its audit action, the
+// type of its target and the state field it sets to value.
+var enforcementAudits = map[string]struct {
+	action, targetType, field string
+	value                     bool
+}{
+	moderation.ActionHideTweet:       {audit.ActionTweetHide, audit.TargetTweet, "hidden", true},
+	moderation.ActionUnhideTweet:     {audit.ActionTweetUnhide, audit.TargetTweet, "hidden", false},
+	moderation.ActionSuspendUser:     {audit.ActionUserSuspend, audit.TargetUser, "suspended", true},
+	moderation.ActionUnsuspendUser:   {audit.ActionUserUnsuspend, audit.TargetUser, "suspended", false},
+	moderation.ActionRestrictReach:   {audit.ActionUserRestrictReach, audit.TargetUser, "reach_restricted", true},
+	moderation.ActionUnrestrictReach: {audit.ActionUserUnrestrictReach, audit.TargetUser, "reach_restricted", false},
+}
+
+// auditEnforcement records an enforcement action in the audit log. before
+// is the state the action changed, as reported by Enforced. Dismissals
+// change nothing and are not recorded.
+func auditEnforcement(c *gin.Context, audits *auditRepo.Repository, action string, targetID int, before bool) {
+	a, ok := enforcementAudits[action]
+	if !ok {
+		return
+	}
+	recordAudit(c, audits, a.action, a.targetType, targetID, gin.H{a.field: before}, gin.H{a.field: a.value})
+}
+
+// syncEnforcement updates the in-memory indexes after an enforcement action
+// so hidden tweets stop matching searches and suspended users stop showing
+// up in autocomplete.
+func syncEnforcement(action string, targetID int, tweets *tweetRepo.Repository, users *userRepo.Repository) {
+	switch action {
+	case moderation.ActionHideTweet, moderation.ActionUnhideTweet:
+		if err := tweets.Reindex(targetID); err != nil {
+			log.Printf("⚠️ Could not reindex tweet %d: %v", targetID, err)
+		}
+	case moderation.ActionSuspendUser:
+		users.Names.Remove(targetID)
+	case moderation.ActionUnsuspendUser:
+		u, err := users.GetByID(targetID)
+		if err != nil {
+			log.Printf("⚠️ Could not restore user %d in the name index: %v", targetID, err)
+			return
+		}
+		if u != nil && u.DeactivatedAt == nil {
+			users.Names.Set(u.Id, u.Name)
+		}
+	}
+}
+
+func validReason(reason string) bool {
+	for _, r := range moderation.Reasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
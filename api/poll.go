@@ -0,0 +1,125 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	poll "ualabackend/entities/poll"
+	pollRepo "ualabackend/repositories/poll"
+	tweetRepo "ualabackend/repositories/tweet"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxPollOptionLength = 25
+
+func pollRoutes(router *gin.Engine, repo *pollRepo.Repository, tweets *tweetRepo.Repository) {
+	router.POST("/tweets/:id/poll/votes", func(c *gin.Context) { votePoll(c, repo, tweets) })
+}
+
+// votePoll godoc
+// @Summary Votar en una encuesta
+// @Description Registra el voto del usuario en la encuesta del tweet. Cada usuario puede votar una sola vez y solo mientras la encuesta esté abierta
+// @Tags tweets
+// @Accept json
+// @Produce json
+// @Param id path int true "ID del tweet"
+// @Param Authorization header string true "Token Bearer del usuario que vota"
+// @Param vote body poll.VoteInput true "Opción elegida (desde 1)"
+// @Success 201 {object} poll.Poll
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /tweets/{id}/poll/votes [post]
+func votePoll(c *gin.Context, repo *pollRepo.Repository, tweets *tweetRepo.Repository) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	userID, ok := requireViewer(c)
+	if !ok {
+		return
+	}
+
+	var input poll.VoteInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos"})
+		return
+	}
+
+	t, err := tweets.GetByID(id, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error interno"})
+		return
+	}
+	if t == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tweet no encontrado"})
+		return
+	}
+
+	err = repo.Vote(id, userID, input.Option)
+	switch {
+	case errors.Is(err, pollRepo.ErrNoPoll):
+		c.JSON(http.StatusNotFound, gin.H{"error": "El tweet no tiene encuesta"})
+		return
+	case errors.Is(err, pollRepo.ErrInvalidOption):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Opción inválida"})
+		return
+	case errors.Is(err, pollRepo.ErrClosed):
+		c.JSON(http.StatusConflict, gin.H{"error": "La encuesta está cerrada"})
+		return
+	case errors.Is(err, pollRepo.ErrAlreadyVoted):
+		c.JSON(http.StatusConflict, gin.H{"error": "Ya votaste en esta encuesta"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo registrar el voto"})
+		return
+	}
+
+	p, err := repo.Get(id, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error interno"})
+		return
+	}
+	c.JSON(http.StatusCreated, p)
+}
+
+// validPoll checks a poll about to be attached to a tweet and fills in the
+// default results visibility, writing a 400 response when it is invalid.
+func validPoll(c *gin.Context, input *poll.PollInput) bool {
+	if len(input.Options) < poll.MinOptions || len(input.Options) > poll.MaxOptions {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La encuesta debe tener entre 2 y 4 opciones"})
+		return false
+	}
+	for i, option := range input.Options {
+		option = strings.TrimSpace(option)
+		if option == "" || utf8.RuneCountInString(option) > maxPollOptionLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Las opciones deben tener entre 1 y 25 caracteres"})
+			return false
+		}
+		input.Options[i] = option
+	}
+
+	duration := time.Duration(input.DurationMinutes) * time.Minute
+	if duration < poll.MinDuration || duration > poll.MaxDuration {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La duración de la encuesta debe estar entre 5 minutos y 7 días"})
+		return false
+	}
+
+	switch input.ResultsVisibility {
+	case "":
+		input.ResultsVisibility = poll.ResultsAfterVote
+	case poll.ResultsAfterVote, poll.ResultsAfterClose:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Visibilidad de resultados inválida"})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"ualabackend/entities/audit"
+	tweet "ualabackend/entities/tweet"
+	auditRepo "ualabackend/repositories/audit"
+	tweetRepo "ualabackend/repositories/tweet"
+	userRepo "ualabackend/repositories/user"
+
+	"github.com/gin-gonic/gin"
+)
+
+func scheduledRoutes(router *gin.Engine, repo *tweetRepo.Repository, users *userRepo.Repository, audits *auditRepo.Repository) {
+	router.GET("/users/:id/scheduled", func(c *gin.Context) { getScheduledTweets(c, repo, users) })
+	router.PUT("/users/:id/scheduled/:scheduled_id", func(c *gin.Context) { rescheduleTweet(c, repo, users, audits) })
+	router.DELETE("/users/:id/scheduled/:scheduled_id", func(c *gin.Context) { cancelScheduledTweet(c, repo, users, audits) })
+}
+
+// getScheduledTweets godoc
+// @Summary Listar tweets programados
+// @Description Devuelve los tweets programados del usuario, el próximo a publicarse primero. Los que el filtro de contenido rechazó al publicarse quedan con estado failed y el motivo en error hasta que se cancelen. Los de una cuenta suspendida o desactivada esperan a que se restablezca. Solo el propio usuario puede verlos
+// @Tags tweets
+// @Produce json
+// @Param id path int true "ID del usuario"
+// @Param Authorization header string true "Token Bearer del usuario que realiza la consulta"
+// @Success 200 {array} tweet.ScheduledTweet
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/scheduled [get]
+func getScheduledTweets(c *gin.Context, repo *tweetRepo.Repository, users *userRepo.Repository) {
+	id, ok := selfOnly(c, users)
+	if !ok {
+		return
+	}
+
+	scheduled, err := repo.GetScheduled(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener tweets programados"})
+		return
+	}
+	c.JSON(http.StatusOK, scheduled)
+}
+
+// rescheduleTweet godoc
+// @Summary Reprogramar un tweet
+// @Description Cambia la fecha de publicación de un tweet programado pendiente
+// @Tags tweets
+// @Accept json
+// @Produce json
+// @Param id path int true "ID del usuario"
+// @Param scheduled_id path int true "ID del tweet programado"
+// @Param Authorization header string true "Token Bearer del usuario que realiza la consulta"
+// @Param schedule body tweet.ScheduleInput true "Nueva fecha de publicación"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/scheduled/{scheduled_id} [put]
+func rescheduleTweet(c *gin.Context, repo *tweetRepo.Repository, users *userRepo.Repository, audits *auditRepo.Repository) {
+	st, ok := ownScheduledTweet(c, repo, users)
+	if !ok {
+		return
+	}
+	if st.Status != tweet.ScheduledPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "El tweet programado no pudo publicarse"})
+		return
+	}
+
+	var input tweet.ScheduleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos"})
+		return
+	}
+	if !input.PublishAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La fecha de publicación debe ser futura"})
+		return
+	}
+
+	if err := repo.Reschedule(st.Id, *input.PublishAt); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo reprogramar el tweet"})
+		return
+	}
+	recordAudit(c, audits, audit.ActionTweetReschedule, audit.TargetScheduledTweet, st.Id, gin.H{"publish_at": st.PublishAt}, gin.H{"publish_at": *input.PublishAt})
+	c.JSON(http.StatusOK, gin.H{"message": "Tweet reprogramado"})
+}
+
+// cancelScheduledTweet godoc
+// @Summary Cancelar un tweet programado
+// @Description Descarta un tweet programado antes de que se publique, o uno que no pudo publicarse
+// @Tags tweets
+// @Produce json
+// @Param id path int true "ID del usuario"
+// @Param scheduled_id path int true "ID del tweet programado"
+// @Param Authorization header string true "Token Bearer del usuario que realiza la consulta"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/scheduled/{scheduled_id} [delete]
+func cancelScheduledTweet(c *gin.Context, repo *tweetRepo.Repository, users *userRepo.Repository, audits *auditRepo.Repository) {
+	st, ok := ownScheduledTweet(c, repo, users)
+	if !ok {
+		return
+	}
+
+	if err := repo.CancelScheduled(st.Id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo cancelar el tweet"})
+		return
+	}
+	recordAudit(c, audits, audit.ActionTweetCancel, audit.TargetScheduledTweet, st.Id, gin.H{
+		"author_id":  st.AuthorID,
+		"message":    st.Message,
+		"publish_at": st.PublishAt,
+	}, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Tweet programado cancelado"})
+}
+
+// ownScheduledTweet resolves the scheduled tweet in the path, checking it was
+// not published yet and belongs to the requesting user.
+func ownScheduledTweet(c *gin.Context, repo *tweetRepo.Repository, users *userRepo.Repository) (*tweet.ScheduledTweet, bool) {
+	userID, ok := selfOnly(c, users)
+	if !ok {
+		return nil, false
+	}
+	scheduledID, err := strconv.Atoi(c.Param("scheduled_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return nil, false
+	}
+
+	st, err := repo.GetScheduledByID(scheduledID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al buscar el tweet programado"})
+		return nil, false
+	}
+	if st == nil || st.AuthorID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tweet programado no encontrado"})
+		return nil, false
+	}
+	return st, true
+}
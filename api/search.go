@@ -0,0 +1,106 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	tweetRepo "ualabackend/repositories/tweet"
+	userRepo "ualabackend/repositories/user"
+	"ualabackend/search"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultAutocompleteSize = 10
+	maxAutocompleteSize     = 50
+)
+
+func searchRoutes(router *gin.Engine, tweets *tweetRepo.Repository, users *userRepo.Repository) {
+	s := router.Group("/search")
+	{
+		s.GET("/tweets", func(c *gin.Context) { searchTweets(c, tweets) })
+		s.GET("/users", func(c *gin.Context) { searchUsers(c, users) })
+	}
+}
+
+// searchTweets godoc
+// @Summary Buscar tweets
+// @Description Búsqueda de texto completo sobre los tweets. Soporta frases entre comillas, from:usuario, from:"nombre con espacios" o from:ID del autor, #hashtag, since:AAAA-MM-DD y until:AAAA-MM-DD
+// @Tags search
+// @Produce json
+// @Param q query string true "Expresión de búsqueda"
+// @Param sort query string false "Orden: relevance o recent" Enums(relevance, recent)
+// @Param limit query int false "Cantidad máxima de resultados"
+// @Param offset query int false "Desplazamiento para paginar"
+// @Param Authorization header string false "Token Bearer del usuario que realiza la búsqueda"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /search/tweets [get]
+func searchTweets(c *gin.Context, repo *tweetRepo.Repository) {
+	q := search.ParseQuery(c.Query("q"))
+	if q.IsEmpty() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El parámetro 'q' es requerido"})
+		return
+	}
+
+	switch sort := c.DefaultQuery("sort", search.SortRelevance); sort {
+	case search.SortRelevance, search.SortRecent:
+		q.Sort = sort
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Orden inválido"})
+		return
+	}
+
+	limit, offset, ok := pagination(c)
+	if !ok {
+		return
+	}
+	q.Limit, q.Offset = limit, offset
+
+	tweets, err := repo.Search(q, viewerID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al buscar tweets"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tweets": tweets})
+}
+
+// searchUsers godoc
+// @Summary Autocompletar usuarios
+// @Description Busca usuarios cuyo nombre comienza con el texto dado, sin distinguir mayúsculas ni acentos. Prioriza a los usuarios que el solicitante ya sigue y luego a los más seguidos
+// @Tags search
+// @Produce json
+// @Param q query string true "Prefijo del nombre"
+// @Param limit query int false "Cantidad máxima de resultados"
+// @Param Authorization header string false "Token Bearer del usuario que realiza la búsqueda"
+// @Success 200 {array} user.UserSummary
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /search/users [get]
+func searchUsers(c *gin.Context, repo *userRepo.Repository) {
+	q := c.Query("q")
+	if len(search.Tokenize(q)) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El parámetro 'q' es requerido"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultAutocompleteSize)))
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Límite inválido"})
+		return
+	}
+	if limit > maxAutocompleteSize {
+		limit = maxAutocompleteSize
+	}
+
+	users, err := repo.SearchByPrefix(q, viewerID(c), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al buscar usuarios"})
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"ualabackend/entities/tweet"
+	"ualabackend/ranking"
+	tweetRepo "ualabackend/repositories/tweet"
+	userRepo "ualabackend/repositories/user"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	timelineChronological = "chronological"
+	timelineRanked        = "ranked"
+)
+
+func timelineRoutes(router *gin.Engine, tweets *tweetRepo.Repository, users *userRepo.Repository, ranker *ranking.Ranker, entities tweetEntities) {
+	u := router.Group("/users")
+	{
+		u.GET("/:id/timeline", func(c *gin.Context) { getTimeline(c, tweets, users, ranker, entities) })
+	}
+}
+
+// getTimeline godoc
+// @Summary Obtener el timeline de un usuario
+// @Description Devuelve los tweets de las cuentas seguidas, del más reciente al más antiguo, sin los de usuarios bloqueados o silenciados ni los que quien consulta no puede ver, como los de cuentas protegidas que no sigue. Con mode=ranked devuelve los tweets de la última semana de las cuentas seguidas y de las que ellas siguen, ordenados por antigüedad, interacciones recibidas y afinidad con el autor. El modo ranked solo está disponible para el propio usuario y devuelve en at el momento con el que se ordenó la página, que debe enviarse al pedir las siguientes para mantener el mismo orden
+// @Tags timeline
+// @Produce json
+// @Param id path int true "ID del usuario"
+// @Param Authorization header string false "Token Bearer del usuario que realiza la consulta; requerido con mode=ranked"
+// @Param mode query string false "Orden del timeline: chronological (por defecto) o ranked"
+// @Param at query string false "Momento en formato RFC 3339 con el que se ordena el timeline ranked (por defecto, ahora)"
+// @Param limit query int false "Cantidad máxima de resultados"
+// @Param offset query int false "Desplazamiento para paginar"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/timeline [get]
+func getTimeline(c *gin.Context, tweets *tweetRepo.Repository, users *userRepo.Repository, ranker *ranking.Ranker, entities tweetEntities) {
+	mode := c.DefaultQuery("mode", timelineChronological)
+	if mode != timelineChronological && mode != timelineRanked {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Modo de timeline inválido"})
+		return
+	}
+
+	// The ranked timeline scores tweets on the user's private interactions,
+	// such as direct messages and poll votes, so only they may see it.
+	var id int
+	var ok bool
+	if mode == timelineRanked {
+		id, ok = selfOnly(c, users)
+	} else {
+		id, ok = existingUserID(c, users)
+	}
+	if !ok {
+		return
+	}
+	limit, offset, ok := pagination(c)
+	if !ok {
+		return
+	}
+
+	response := gin.H{}
+	var timeline []tweet.Tweet
+	var err error
+	if mode == timelineRanked {
+		var at time.Time
+		if v := c.Query("at"); v != "" {
+			if at, err = time.Parse(time.RFC3339, v); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Fecha 'at' inválida"})
+				return
+			}
+		}
+		timeline, at, err = ranker.Timeline(id, at, limit, offset)
+		response["at"] = at
+	} else {
+		timeline, err = tweets.Timeline(id, viewerID(c), limit, offset)
+	}
+	if err == nil {
+		err = entities.fill(timeline)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo obtener el timeline"})
+		return
+	}
+	response["tweets"] = timeline
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"ualabackend/trends"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultTrendsLimit = 10
+	maxTrendsLimit     = 50
+)
+
+func trendRoutes(router *gin.Engine, tracker *trends.Tracker) {
+	router.GET("/trends", func(c *gin.Context) { getTrends(c, tracker) })
+}
+
+// getTrends godoc
+// @Summary Obtener las tendencias
+// @Description Devuelve los hashtags y términos cuyo volumen de tweets en la última hora crece más respecto de su volumen habitual en las 24 horas anteriores, de mayor a menor
+// @Tags trends
+// @Produce json
+// @Param limit query int false "Cantidad máxima de tendencias (por defecto 10, máximo 50)"
+// @Success 200 {array} trends.Trend
+// @Failure 400 {object} map[string]string
+// @Router /trends [get]
+func getTrends(c *gin.Context, tracker *trends.Tracker) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultTrendsLimit)))
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Límite inválido"})
+		return
+	}
+	if limit > maxTrendsLimit {
+		limit = maxTrendsLimit
+	}
+	c.JSON(http.StatusOK, tracker.Trends(limit))
+}
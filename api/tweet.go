@@ -1,12 +1,21 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"ualabackend/entities/audit"
 	tweet "ualabackend/entities/tweet"
+	"ualabackend/filter"
+	auditRepo "ualabackend/repositories/audit"
+	linkRepo "ualabackend/repositories/link"
+	mediaRepo "ualabackend/repositories/media"
+	pollRepo "ualabackend/repositories/poll"
 	tweetRepo "ualabackend/repositories/tweet"
+	userRepo "ualabackend/repositories/user"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,27 +24,34 @@ type UpdateTweetRequest struct {
 	Message string `json:"message" binding:"required"`
 }
 
-func tweetRoutes(router *gin.Engine, repo *tweetRepo.Repository) {
+func tweetRoutes(router *gin.Engine, repo *tweetRepo.Repository, polls *pollRepo.Repository, users *userRepo.Repository, entities tweetEntities, audits *auditRepo.Repository, restoreWindow time.Duration) {
+	owner := requireOwner(tweetOwner(repo))
 
 	tweets := router.Group("/tweets")
 	{
-		tweets.GET("/", func(c *gin.Context) { getAllTweets(c, repo) })
-		tweets.POST("/", func(c *gin.Context) { createTweet(c, repo) })
-		tweets.GET("/:id", func(c *gin.Context) { getTweetByID(c, repo) })
-		tweets.PUT("/:id", func(c *gin.Context) { updateTweet(c, repo) })
-		tweets.DELETE("/:id", func(c *gin.Context) { deleteTweet(c, repo) })
+		tweets.GET("/", func(c *gin.Context) { getAllTweets(c, repo, entities) })
+		tweets.POST("/", func(c *gin.Context) { createTweet(c, repo, audits) })
+		tweets.GET("/:id", func(c *gin.Context) { getTweetByID(c, repo, polls, entities) })
+		tweets.PUT("/:id", owner, func(c *gin.Context) { updateTweet(c, repo, audits) })
+		tweets.DELETE("/:id", requireOwnerOrModerator(users, tweetOwner(repo)), func(c *gin.Context) { deleteTweet(c, repo, audits) })
+		tweets.GET("/:id/history", func(c *gin.Context) { getTweetHistory(c, repo) })
+		tweets.POST("/:id/restore", owner, func(c *gin.Context) { restoreTweet(c, repo, audits, restoreWindow) })
 	}
 }
 
 // getAllTweets godoc
 // @Summary Obtener todos los tweets
-// @Description Devuelve una lista de todos los tweets
+// @Description Devuelve una lista de todos los tweets, sin los de cuentas bloqueadas por el usuario o que lo bloquearon
 // @Tags tweets
 // @Produce json
+// @Param Authorization header string false "Token Bearer del usuario que realiza la consulta"
 // @Success 200 {object} map[string]interface{}
 // @Router /tweets/ [get]
-func getAllTweets(c *gin.Context, repo *tweetRepo.Repository) {
-	tweets, err := repo.GetAll()
+func getAllTweets(c *gin.Context, repo *tweetRepo.Repository, entities tweetEntities) {
+	tweets, err := repo.GetAll(viewerID(c))
+	if err == nil {
+		err = entities.fill(tweets)
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron obtener los tweets"})
 		return
@@ -44,53 +60,175 @@ func getAllTweets(c *gin.Context, repo *tweetRepo.Repository) {
 }
 
 // @Summary Crear un nuevo tweet
-// @Description Crea un nuevo tweet en el sistema
+// @Description Crea un nuevo tweet en el sistema. Si se indica publish_at, el tweet queda programado y se publica en esa fecha. El mensaje pasa por el filtro de contenido, que puede rechazarlo (422), retenerlo hasta que un moderador lo revise (202) o etiquetarlo. Los tweets programados pasan el filtro al publicarse
 // @Tags tweets
 // @Accept json
 // @Produce json
+// @Param Authorization header string true "Token Bearer del autor"
 // @Param tweet body tweet.TweetInput true "Datos del tweet"
-// @Success 201 {object} map[string]string
+// @Success 201 {object} map[string]interface{}
+// @Success 202 {object} map[string]string
 // @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 422 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /tweets/ [post]
-func createTweet(c *gin.Context, repo *tweetRepo.Repository) {
+func createTweet(c *gin.Context, repo *tweetRepo.Repository, audits *auditRepo.Repository) {
 	var input tweet.TweetInput
 
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos"})
 		return
 	}
+	authorID, ok := requireViewer(c)
+	if !ok {
+		return
+	}
+	if input.AuthorID != 0 && input.AuthorID != authorID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Solo se puede publicar como el usuario autenticado"})
+		return
+	}
+
+	if !validMessage(c, input.Message) {
+		return
+	}
 
 	t := tweet.Tweet{
 		Timestamp: time.Now(),
 		Message:   input.Message,
-		Author_id: input.AuthorID,
+		Author_id: authorID,
+	}
+
+	if input.PublishAt != nil {
+		if input.Poll != nil || len(input.MediaIDs) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Los tweets programados no pueden incluir encuestas ni archivos multimedia"})
+			return
+		}
+		if !input.PublishAt.After(t.Timestamp) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "La fecha de publicación debe ser futura"})
+			return
+		}
+		id, err := repo.Schedule(t.Author_id, t.Message, *input.PublishAt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo programar el tweet"})
+			return
+		}
+		recordAudit(c, audits, audit.ActionTweetSchedule, audit.TargetScheduledTweet, int(id), nil, gin.H{
+			"author_id":  t.Author_id,
+			"message":    t.Message,
+			"publish_at": *input.PublishAt,
+		})
+		c.JSON(http.StatusCreated, gin.H{"message": "Tweet programado", "id": id})
+		return
+	}
+
+	if input.Poll != nil && !validPoll(c, input.Poll) {
+		return
+	}
+	if len(input.MediaIDs) > tweetRepo.MaxMedia {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Un tweet puede incluir hasta 4 archivos multimedia"})
+		return
 	}
 
-	if err := repo.Create(t.Author_id, t.Message); err != nil {
+	id, decision, err := repo.CreateWithAttachments(t.Author_id, t.Message, input.Poll, input.MediaIDs)
+	if errors.Is(err, tweetRepo.ErrInvalidMedia) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Archivo multimedia inválido o ya utilizado"})
+		return
+	}
+	if errors.Is(err, tweetRepo.ErrRejected) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "El mensaje infringe las reglas de contenido"})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo crear el tweet"})
 		return
 	}
+	created := gin.H{
+		"author_id": t.Author_id,
+		"message":   t.Message,
+		"hidden":    decision.Action == filter.ActionHold,
+	}
+	if len(decision.Labels) > 0 {
+		created["labels"] = decision.Labels
+	}
+	recordAudit(c, audits, audit.ActionTweetCreate, audit.TargetTweet, int(id), nil, created)
+
+	filteredResponse(c, http.StatusCreated, "Tweet creado", decision)
+}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "Tweet creado"})
+// filteredResponse writes the response for a message stored after going
+// through the content filter: 202 when it was held for review, and status
+// with the labels it got otherwise.
+func filteredResponse(c *gin.Context, status int, message string, decision filter.Decision) {
+	if decision.Action == filter.ActionHold {
+		c.JSON(http.StatusAccepted, gin.H{"message": "Tweet retenido para revisión"})
+		return
+	}
+	body := gin.H{"message": message}
+	if len(decision.Labels) > 0 {
+		body["labels"] = decision.Labels
+	}
+	c.JSON(status, body)
+}
+
+// tweetEntities loads what is attached to tweets in responses: media and
+// link entities.
+type tweetEntities struct {
+	media *mediaRepo.Repository
+	links *linkRepo.Repository
+}
+
+func (e tweetEntities) fill(tweets []tweet.Tweet) error {
+	ids := make([]int, len(tweets))
+	for i, t := range tweets {
+		ids[i] = t.Id
+	}
+
+	attached, err := e.media.ForTweets(ids)
+	if err != nil {
+		return err
+	}
+	found, err := e.links.ForTweets(ids)
+	if err != nil {
+		return err
+	}
+	for i := range tweets {
+		tweets[i].Media = attached[tweets[i].Id]
+		tweets[i].Links = found[tweets[i].Id]
+	}
+	return nil
+}
+
+// validMessage checks the text of a tweet about to be published, writing a
+// 400 response when it is rejected. Every path that publishes a tweet goes
+// through it.
+func validMessage(c *gin.Context, message string) bool {
+	if strings.TrimSpace(message) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El mensaje no puede estar vacío"})
+		return false
+	}
+	return true
 }
 
 // getTweetByID godoc
 // @Summary Obtener tweet por ID
-// @Description Devuelve un tweet según el ID proporcionado
+// @Description Devuelve un tweet según el ID proporcionado, con su encuesta si la tiene. Responde 404 si el autor y el usuario tienen un bloqueo entre sí. Los resultados de la encuesta se incluyen según su configuración de visibilidad
 // @Tags tweets
 // @Produce json
 // @Param id path int true "ID del tweet"
+// @Param Authorization header string false "Token Bearer del usuario que realiza la consulta"
 // @Success 200 {object} map[string]interface{}
 // @Router /tweets/{id} [get]
-func getTweetByID(c *gin.Context, repo *tweetRepo.Repository) {
+func getTweetByID(c *gin.Context, repo *tweetRepo.Repository, polls *pollRepo.Repository, entities tweetEntities) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
 		return
 	}
 
-	t, err := repo.GetByID(id)
+	viewer := viewerID(c)
+	t, err := repo.GetByID(id, viewer)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error interno"})
 		return
@@ -100,20 +238,37 @@ func getTweetByID(c *gin.Context, repo *tweetRepo.Repository) {
 		return
 	}
 
+	if t.Poll, err = polls.Get(id, viewer); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error interno"})
+		return
+	}
+	tweets := []tweet.Tweet{*t}
+	if err := entities.fill(tweets); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error interno"})
+		return
+	}
+	t = &tweets[0]
+
 	c.JSON(http.StatusOK, gin.H{"tweet": t})
 }
 
 // updateTweet godoc
 // @Summary Actualizar un tweet
-// @Description Actualiza el contenido de un tweet
+// @Description Guarda una nueva versión del tweet. Solo se permite dentro de la ventana de edición y hasta una cantidad máxima de ediciones. El nuevo mensaje pasa por el filtro de contenido como un tweet nuevo
 // @Tags tweets
 // @Accept json
 // @Produce json
 // @Param id path int true "ID del tweet"
+// @Param Authorization header string true "Token Bearer del autor"
 // @Param message body UpdateTweetRequest true "Nuevo mensaje en el cuerpo"
 // @Success 200 {object} map[string]interface{}
+// @Success 202 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]string
 // @Router /tweets/{id} [put]
-func updateTweet(c *gin.Context, repo *tweetRepo.Repository) {
+func updateTweet(c *gin.Context, repo *tweetRepo.Repository, audits *auditRepo.Repository) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
@@ -129,33 +284,165 @@ func updateTweet(c *gin.Context, repo *tweetRepo.Repository) {
 		return
 	}
 
-	if err := repo.Update(id, input.Message); err != nil {
+	before, _, err := repo.Message(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al buscar el tweet"})
+		return
+	}
+
+	decision, err := repo.Update(id, input.Message)
+	switch {
+	case errors.Is(err, tweetRepo.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tweet no encontrado"})
+		return
+	case errors.Is(err, tweetRepo.ErrEditWindowClosed):
+		c.JSON(http.StatusForbidden, gin.H{"error": "El tiempo para editar el tweet expiró"})
+		return
+	case errors.Is(err, tweetRepo.ErrTooManyEdits):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Se alcanzó la cantidad máxima de ediciones"})
+		return
+	case errors.Is(err, tweetRepo.ErrRejected):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "El mensaje infringe las reglas de contenido"})
+		return
+	case err != nil:
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo actualizar el tweet"})
 		return
 	}
+	recordAudit(c, audits, audit.ActionTweetUpdate, audit.TargetTweet, id, gin.H{"message": before}, gin.H{"message": input.Message})
 
-	c.JSON(http.StatusOK, gin.H{"message": "Tweet actualizado"})
+	filteredResponse(c, http.StatusOK, "Tweet actualizado", decision)
 }
 
 // deleteTweet godoc
 // @Summary Eliminar un tweet
-// @Description Elimina un tweet por ID
+// @Description Elimina un tweet por ID. Se quita de los timelines y puede restaurarse hasta que se purga definitivamente
 // @Tags tweets
 // @Produce json
 // @Param id path int true "ID del tweet"
+// @Param Authorization header string true "Token Bearer del autor o de un moderador"
 // @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Router /tweets/{id} [delete]
-func deleteTweet(c *gin.Context, repo *tweetRepo.Repository) {
+func deleteTweet(c *gin.Context, repo *tweetRepo.Repository, audits *auditRepo.Repository) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
 		return
 	}
 
+	message, deleted, err := repo.Message(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo eliminar el tweet"})
+		return
+	}
 	if err := repo.Delete(id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo eliminar el tweet"})
 		return
 	}
+	if message != "" && !deleted {
+		recordAudit(c, audits, audit.ActionTweetDelete, audit.TargetTweet, id, gin.H{"deleted": false}, gin.H{"deleted": true})
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Tweet eliminado"})
 }
+
+// getTweetHistory godoc
+// @Summary Historial de ediciones de un tweet
+// @Description Devuelve todas las versiones del tweet, de la original a la actual
+// @Tags tweets
+// @Produce json
+// @Param id path int true "ID del tweet"
+// @Param Authorization header string false "Token Bearer del usuario que realiza la consulta"
+// @Success 200 {array} tweet.Revision
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /tweets/{id}/history [get]
+func getTweetHistory(c *gin.Context, repo *tweetRepo.Repository) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	t, err := repo.GetByID(id, viewerID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error interno"})
+		return
+	}
+	if t == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tweet no encontrado"})
+		return
+	}
+
+	history, err := repo.History(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo obtener el historial"})
+		return
+	}
+	if len(history) == 0 {
+		history = []tweet.Revision{{TweetID: t.Id, Revision: 1, Message: t.Message, CreatedAt: t.Timestamp}}
+	}
+	c.JSON(http.StatusOK, history)
+}
+
+// restoreTweet godoc
+// @Summary Restaurar un tweet eliminado
+// @Description Restaura un tweet eliminado dentro del período de retención y lo vuelve a agregar a los timelines
+// @Tags tweets
+// @Produce json
+// @Param id path int true "ID del tweet"
+// @Param Authorization header string true "Token Bearer del autor"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /tweets/{id}/restore [post]
+func restoreTweet(c *gin.Context, repo *tweetRepo.Repository, audits *auditRepo.Repository, window time.Duration) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	err = repo.Restore(id, window)
+	switch {
+	case errors.Is(err, tweetRepo.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tweet eliminado no encontrado"})
+		return
+	case errors.Is(err, tweetRepo.ErrRestoreWindowClosed):
+		c.JSON(http.StatusForbidden, gin.H{"error": "El tiempo para restaurar el tweet expiró"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo restaurar el tweet"})
+		return
+	}
+	recordAudit(c, audits, audit.ActionTweetRestore, audit.TargetTweet, id, gin.H{"deleted": true}, gin.H{"deleted": false})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tweet restaurado"})
+}
+
+// tweetOwner resolves the author of the tweet in the path, writing a 404
+// response when it does not exist.
+func tweetOwner(repo *tweetRepo.Repository) func(c *gin.Context) (int, bool) {
+	return func(c *gin.Context) (int, bool) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+			return 0, false
+		}
+		authorID, err := repo.AuthorOf(id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al buscar el tweet"})
+			return 0, false
+		}
+		if authorID == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tweet no encontrado"})
+			return 0, false
+		}
+		return authorID, true
+	}
+}
@@ -1,23 +1,37 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
+	"ualabackend/auth"
+	"ualabackend/entities/audit"
 	user "ualabackend/entities/user"
+	auditRepo "ualabackend/repositories/audit"
+	followRepo "ualabackend/repositories/follow"
 	userRepo "ualabackend/repositories/user"
+	"ualabackend/suggestions"
 
 	"github.com/gin-gonic/gin"
 )
 
-func userRoutes(router *gin.Engine, repo *userRepo.Repository) {
+func userRoutes(router *gin.Engine, repo *userRepo.Repository, follows *followRepo.Repository, suggester *suggestions.Service, signer *auth.Signer, audits *auditRepo.Repository, grace time.Duration) {
+	owner := requireOwner(pathOwner("id"))
 	users := router.Group("/users")
 	{
 		users.GET("/", func(c *gin.Context) { getAllUsers(c, repo) })
-		users.POST("/", func(c *gin.Context) { createUser(c, repo) })
+		users.POST("/", func(c *gin.Context) { createUser(c, repo, signer, audits) })
 		users.GET("/:id", func(c *gin.Context) { getUserByID(c, repo) })
-		users.PUT("/:id", func(c *gin.Context) { updateUser(c, repo) })
-		users.DELETE("/:id", func(c *gin.Context) { deleteUser(c, repo) })
+		users.PUT("/:id", owner, func(c *gin.Context) { updateUser(c, repo, audits) })
+		users.DELETE("/:id", owner, func(c *gin.Context) { deleteUser(c, repo, suggester, audits, grace) })
+		users.POST("/:id/reactivate", owner, func(c *gin.Context) { reactivateUser(c, repo, audits, grace) })
+		users.PUT("/:id/protected", owner, func(c *gin.Context) { setProtected(c, repo, audits) })
+		users.GET("/:id/followers", func(c *gin.Context) { getFollowers(c, repo, follows) })
+		users.GET("/:id/following", func(c *gin.Context) { getFollowing(c, repo, follows) })
+		users.GET("/:id/relationship/:other_id", func(c *gin.Context) { getRelationship(c, repo, follows) })
+		users.GET("/:id/suggestions", func(c *gin.Context) { getSuggestions(c, repo, suggester) })
 	}
 }
 
@@ -39,7 +53,7 @@ func getAllUsers(c *gin.Context, repo *userRepo.Repository) {
 
 // createUser godoc
 // @Summary Crear un nuevo usuario
-// @Description Crea un usuario en el sistema
+// @Description Crea un usuario en el sistema y devuelve un token de acceso para él, que debe enviarse como "Authorization: Bearer <token>"
 // @Tags usuarios
 // @Accept json
 // @Produce json
@@ -48,19 +62,22 @@ func getAllUsers(c *gin.Context, repo *userRepo.Repository) {
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /users/ [post]
-func createUser(c *gin.Context, repo *userRepo.Repository) {
+func createUser(c *gin.Context, repo *userRepo.Repository, signer *auth.Signer, audits *auditRepo.Repository) {
 	var payload user.UserInput
 	if err := c.ShouldBindJSON(&payload); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos"})
 		return
 	}
 
-	err := repo.Create(payload.Name)
+	id, err := repo.Create(payload.Name)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo crear el usuario"})
 		return
 	}
-	c.JSON(http.StatusCreated, gin.H{"message": "Usuario creado"})
+	recordAudit(c, audits, audit.ActionUserCreate, audit.TargetUser, id, nil, gin.H{"name": payload.Name})
+
+	token, expires := signer.Issue(id)
+	c.JSON(http.StatusCreated, gin.H{"message": "Usuario creado", "id": id, "token": token, "expires_at": expires})
 }
 
 // getUserByID godoc
@@ -83,7 +100,7 @@ func getUserByID(c *gin.Context, repo *userRepo.Repository) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al buscar usuario"})
 		return
 	}
-	if u == nil {
+	if u == nil || u.DeactivatedAt != nil || u.SuspendedAt != nil {
 		c.JSON(http.StatusNotFound, gin.H{"message": "Usuario no encontrado"})
 		return
 	}
@@ -98,10 +115,13 @@ func getUserByID(c *gin.Context, repo *userRepo.Repository) {
 // @Accept json
 // @Produce json
 // @Param id path int true "ID del usuario"
+// @Param Authorization header string true "Token Bearer del usuario"
 // @Param name query string true "Nuevo nombre del usuario"
 // @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Router /users/{id} [put]
-func updateUser(c *gin.Context, repo *userRepo.Repository) {
+func updateUser(c *gin.Context, repo *userRepo.Repository, audits *auditRepo.Repository) {
 	// Get the user ID from the URL path
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
@@ -116,12 +136,21 @@ func updateUser(c *gin.Context, repo *userRepo.Repository) {
 		return
 	}
 
+	before, err := repo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al buscar usuario"})
+		return
+	}
+
 	// Call the repository's Update method
 	err = repo.Update(id, name)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo actualizar el usuario"})
 		return
 	}
+	if before != nil {
+		recordAudit(c, audits, audit.ActionUserUpdate, audit.TargetUser, id, gin.H{"name": before.Name}, gin.H{"name": name})
+	}
 
 	// Return success response
 	c.JSON(http.StatusOK, gin.H{"message": "Usuario actualizado"})
@@ -129,23 +158,285 @@ func updateUser(c *gin.Context, repo *userRepo.Repository) {
 
 // deleteUser godoc
 // @Summary Eliminar un usuario
-// @Description Elimina un usuario por ID
+// @Description Desactiva la cuenta y la oculta en toda la API. Puede reactivarse durante el período de gracia; pasado ese plazo se eliminan definitivamente la cuenta, sus tweets, follows y demás datos asociados
 // @Tags usuarios
 // @Produce json
 // @Param id path int true "ID del usuario"
-// @Success 200 {object} map[string]interface{}
+// @Param Authorization header string true "Token Bearer del usuario"
+// @Success 202 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
 // @Router /users/{id} [delete]
-func deleteUser(c *gin.Context, repo *userRepo.Repository) {
+func deleteUser(c *gin.Context, repo *userRepo.Repository, suggester *suggestions.Service, audits *auditRepo.Repository, grace time.Duration) {
+	id, ok := existingUserID(c, repo)
+	if !ok {
+		return
+	}
+
+	if err := repo.Deactivate(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo eliminar el usuario"})
+		return
+	}
+	suggester.Evict(id)
+	recordAudit(c, audits, audit.ActionUserDeactivate, audit.TargetUser, id, gin.H{"deactivated": false}, gin.H{"deactivated": true})
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":      "Usuario desactivado",
+		"delete_after": time.Now().Add(grace),
+	})
+}
+
+// reactivateUser godoc
+// @Summary Reactivar un usuario
+// @Description Cancela la eliminación de una cuenta desactivada dentro del período de gracia
+// @Tags usuarios
+// @Produce json
+// @Param id path int true "ID del usuario"
+// @Param Authorization header string true "Token Bearer del usuario"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/reactivate [post]
+func reactivateUser(c *gin.Context, repo *userRepo.Repository, audits *auditRepo.Repository, grace time.Duration) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
 		return
 	}
 
-	err = repo.Delete(id)
+	err = repo.Reactivate(id, grace)
+	switch {
+	case errors.Is(err, userRepo.ErrNotDeactivated):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Usuario desactivado no encontrado"})
+		return
+	case errors.Is(err, userRepo.ErrGracePeriodOver):
+		c.JSON(http.StatusForbidden, gin.H{"error": "El período para reactivar la cuenta expiró"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo reactivar el usuario"})
+		return
+	}
+	recordAudit(c, audits, audit.ActionUserReactivate, audit.TargetUser, id, gin.H{"deactivated": true}, gin.H{"deactivated": false})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Usuario reactivado"})
+}
+
+// setProtected godoc
+// @Summary Proteger o desproteger una cuenta
+// @Description Las cuentas protegidas reciben solicitudes de follow en lugar de follows directos y sus tweets solo son visibles para seguidores aprobados
+// @Tags usuarios
+// @Accept json
+// @Produce json
+// @Param id path int true "ID del usuario"
+// @Param Authorization header string true "Token Bearer del usuario"
+// @Param protected body user.ProtectedInput true "Nuevo estado"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/protected [put]
+func setProtected(c *gin.Context, repo *userRepo.Repository, audits *auditRepo.Repository) {
+	u, ok := existingUser(c, repo)
+	if !ok {
+		return
+	}
+
+	var payload user.ProtectedInput
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos"})
+		return
+	}
+
+	if err := repo.SetProtected(u.Id, *payload.Protected); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo actualizar el usuario"})
+		return
+	}
+	recordAudit(c, audits, audit.ActionUserProtect, audit.TargetUser, u.Id, gin.H{"protected": u.Protected}, gin.H{"protected": *payload.Protected})
+	c.JSON(http.StatusOK, gin.H{"message": "Usuario actualizado"})
+}
+
+// getFollowers godoc
+// @Summary Listar seguidores de un usuario
+// @Description Devuelve los usuarios que siguen al usuario indicado, del follow más reciente al más antiguo
+// @Tags usuarios
+// @Produce json
+// @Param id path int true "ID del usuario"
+// @Param limit query int false "Cantidad máxima de resultados"
+// @Param offset query int false "Desplazamiento para paginar"
+// @Param Authorization header string false "Token Bearer del usuario que realiza la consulta"
+// @Success 200 {array} user.UserSummary
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/followers [get]
+func getFollowers(c *gin.Context, repo *userRepo.Repository, follows *followRepo.Repository) {
+	id, ok := existingUserID(c, repo)
+	if !ok {
+		return
+	}
+	limit, offset, ok := pagination(c)
+	if !ok {
+		return
+	}
+
+	users, err := follows.GetFollowers(id, viewerID(c), limit, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo eliminar el usuario"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener seguidores"})
+		return
+	}
+	c.JSON(http.StatusOK, users)
+}
+
+// getFollowing godoc
+// @Summary Listar seguidos de un usuario
+// @Description Devuelve los usuarios seguidos por el usuario indicado, del follow más reciente al más antiguo
+// @Tags usuarios
+// @Produce json
+// @Param id path int true "ID del usuario"
+// @Param limit query int false "Cantidad máxima de resultados"
+// @Param offset query int false "Desplazamiento para paginar"
+// @Param Authorization header string false "Token Bearer del usuario que realiza la consulta"
+// @Success 200 {array} user.UserSummary
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/following [get]
+func getFollowing(c *gin.Context, repo *userRepo.Repository, follows *followRepo.Repository) {
+	id, ok := existingUserID(c, repo)
+	if !ok {
+		return
+	}
+	limit, offset, ok := pagination(c)
+	if !ok {
+		return
+	}
+
+	users, err := follows.GetFollowing(id, viewerID(c), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener seguidos"})
+		return
+	}
+	c.JSON(http.StatusOK, users)
+}
+
+// getRelationship godoc
+// @Summary Obtener la relación entre dos usuarios
+// @Description Indica si el usuario sigue al otro, si es seguido por él, si es mutuo y si hay bloqueos en alguna dirección
+// @Tags usuarios
+// @Produce json
+// @Param id path int true "ID del usuario de origen"
+// @Param other_id path int true "ID del otro usuario"
+// @Success 200 {object} follow.Relationship
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/relationship/{other_id} [get]
+func getRelationship(c *gin.Context, repo *userRepo.Repository, follows *followRepo.Repository) {
+	source, ok := existingUser(c, repo)
+	if !ok {
+		return
+	}
+	target, ok := existingUserParam(c, repo, "other_id")
+	if !ok {
+		return
+	}
+
+	rel, err := follows.GetRelationship(source.Id, target.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la relación"})
+		return
+	}
+	c.JSON(http.StatusOK, rel)
+}
+
+// getSuggestions godoc
+// @Summary Sugerencias de a quién seguir
+// @Description Recomienda cuentas a partir del grafo de follows (amigos de amigos, ordenados por cantidad de conexiones en común) y, si la red del usuario es chica, las cuentas más populares
+// @Tags usuarios
+// @Produce json
+// @Param id path int true "ID del usuario"
+// @Param limit query int false "Cantidad máxima de resultados"
+// @Param offset query int false "Desplazamiento para paginar"
+// @Success 200 {array} follow.Suggestion
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/suggestions [get]
+func getSuggestions(c *gin.Context, repo *userRepo.Repository, suggester *suggestions.Service) {
+	id, ok := existingUserID(c, repo)
+	if !ok {
+		return
+	}
+	limit, offset, ok := pagination(c)
+	if !ok {
+		return
+	}
+
+	items, err := suggester.Get(id, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener sugerencias"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "Usuario eliminado"})
+	c.JSON(http.StatusOK, items)
+}
+
+// existingUserID parses the :id path parameter and checks that the user
+// exists and is active, writing the error response otherwise.
+func existingUserID(c *gin.Context, repo *userRepo.Repository) (int, bool) {
+	u, ok := existingUser(c, repo)
+	if !ok {
+		return 0, false
+	}
+	return u.Id, true
+}
+
+// existingUser is like existingUserID but returns the whole user.
+func existingUser(c *gin.Context, repo *userRepo.Repository) (*user.User, bool) {
+	return existingUserParam(c, repo, "id")
+}
+
+// existingUserParam is like existingUser for the user ID in the path
+// parameter param.
+func existingUserParam(c *gin.Context, repo *userRepo.Repository, param string) (*user.User, bool) {
+	id, err := strconv.Atoi(c.Param(param))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return nil, false
+	}
+
+	u, err := repo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al buscar usuario"})
+		return nil, false
+	}
+	if u == nil || u.DeactivatedAt != nil || u.SuspendedAt != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+		return nil, false
+	}
+	return u, true
+}
+
+// selfOnly resolves the user in the path and checks the request comes from
+// that same user, for resources nobody else may see.
+func selfOnly(c *gin.Context, repo *userRepo.Repository) (int, bool) {
+	id, ok := existingUserID(c, repo)
+	if !ok {
+		return 0, false
+	}
+	viewer, ok := requireViewer(c)
+	if !ok {
+		return 0, false
+	}
+	if viewer != id {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Solo el propio usuario puede acceder a este recurso"})
+		return 0, false
+	}
+	return id, true
 }
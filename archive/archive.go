@@ -0,0 +1,257 @@
+package archive
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"ualabackend/entities/export"
+	"ualabackend/entities/follow"
+	"ualabackend/entities/tweet"
+	"ualabackend/entities/user"
+	exportRepo "ualabackend/repositories/export"
+)
+
+// Users loads the profile being exported.
+type Users interface {
+	GetByID(id int) (*user.User, error)
+}
+
+// Tweets loads the tweets written by the user being exported.
+type Tweets interface {
+	ByAuthor(authorID int) ([]tweet.Tweet, error)
+}
+
+// Follows loads both directions of the user's follow graph.
+type Follows interface {
+	GetFollowedByFollowerID(followerID int) ([]follow.Follow, error)
+	GetFollowersByFollowedID(followedID int) ([]follow.Follow, error)
+}
+
+// Service builds personal data archives in the background. Each archive is a
+// ZIP with the profile, tweets, follows and followers as JSON and CSV, kept in
+// dir until its job expires.
+type Service struct {
+	jobs    *exportRepo.Repository
+	users   Users
+	tweets  Tweets
+	follows Follows
+	dir     string
+	ttl     time.Duration
+}
+
+func NewService(jobs *exportRepo.Repository, users Users, tweets Tweets, follows Follows, dir string, ttl time.Duration) *Service {
+	return &Service{
+		jobs:    jobs,
+		users:   users,
+		tweets:  tweets,
+		follows: follows,
+		dir:     dir,
+		ttl:     ttl,
+	}
+}
+
+// Start registers an export for userID and builds it asynchronously. The
+// returned job is still pending.
+func (s *Service) Start(userID int) (*export.Job, error) {
+	job, err := s.jobs.Create(userID)
+	if err != nil {
+		return nil, err
+	}
+	go s.build(*job)
+	return job, nil
+}
+
+// Resume rebuilds the exports that were interrupted by a restart.
+func (s *Service) Resume() error {
+	jobs, err := s.jobs.Unfinished()
+	if err != nil {
+		return err
+	}
+	go func() {
+		for _, job := range jobs {
+			s.build(job)
+		}
+	}()
+	return nil
+}
+
+// Cleanup deletes the archives whose download window has passed.
+func (s *Service) Cleanup() error {
+	jobs, err := s.jobs.ExpiredBefore(time.Now())
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if err := os.Remove(job.FilePath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err := s.jobs.MarkExpired(job.Id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) build(job export.Job) {
+	if err := s.jobs.MarkRunning(job.Id); err != nil {
+		log.Printf("⚠️ Could not start export %s: %v", job.Id, err)
+		return
+	}
+
+	path := filepath.Join(s.dir, job.Id+".zip")
+	if err := s.write(job.UserID, path); err != nil {
+		log.Printf("⚠️ Export %s failed: %v", job.Id, err)
+		os.Remove(path)
+		if err := s.jobs.MarkFailed(job.Id, err); err != nil {
+			log.Printf("⚠️ Could not record failure of export %s: %v", job.Id, err)
+		}
+		return
+	}
+
+	if err := s.jobs.MarkDone(job.Id, path, time.Now().Add(s.ttl)); err != nil {
+		log.Printf("⚠️ Could not complete export %s: %v", job.Id, err)
+	}
+}
+
+// write builds the archive in a temporary file and moves it into place once
+// complete, so a crash never leaves a truncated ZIP behind.
+func (s *Service) write(userID int, path string) error {
+	profile, err := s.users.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if profile == nil {
+		return fmt.Errorf("user %d not found", userID)
+	}
+	tweets, err := s.tweets.ByAuthor(userID)
+	if err != nil {
+		return err
+	}
+	following, err := s.follows.GetFollowedByFollowerID(userID)
+	if err != nil {
+		return err
+	}
+	followers, err := s.follows.GetFollowersByFollowedID(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.dir, "export-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	zw := zip.NewWriter(tmp)
+	files := []struct {
+		name   string
+		data   interface{}
+		header []string
+		rows   [][]string
+	}{
+		{"profile", profile, profileHeader, [][]string{profileRow(*profile)}},
+		{"tweets", tweets, tweetHeader, tweetRows(tweets)},
+		{"following", following, followHeader, followRows(following)},
+		{"followers", followers, followHeader, followRows(followers)},
+	}
+	for _, f := range files {
+		if err := writeJSON(zw, f.name+".json", f.data); err != nil {
+			return err
+		}
+		if err := writeCSV(zw, f.name+".csv", f.header, f.rows); err != nil {
+			return err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func writeJSON(zw *zip.Writer, name string, data interface{}) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(data)
+}
+
+func writeCSV(zw *zip.Writer, name string, header []string, rows [][]string) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+var (
+	profileHeader = []string{"id", "name", "protected", "allow_dms_from_anyone", "followers_count", "following_count", "tweet_count"}
+	tweetHeader   = []string{"id", "message", "timestamp", "edited_at", "edit_count"}
+	followHeader  = []string{"follower_id", "followed_id", "created_at"}
+)
+
+func profileRow(u user.User) []string {
+	return []string{
+		strconv.Itoa(u.Id),
+		u.Name,
+		strconv.FormatBool(u.Protected),
+		strconv.FormatBool(u.AllowDMs),
+		strconv.Itoa(u.FollowersCount),
+		strconv.Itoa(u.FollowingCount),
+		strconv.Itoa(u.TweetCount),
+	}
+}
+
+func tweetRows(tweets []tweet.Tweet) [][]string {
+	rows := make([][]string, 0, len(tweets))
+	for _, t := range tweets {
+		editedAt := ""
+		if t.EditedAt != nil {
+			editedAt = t.EditedAt.Format(time.RFC3339)
+		}
+		rows = append(rows, []string{
+			strconv.Itoa(t.Id),
+			t.Message,
+			t.Timestamp.Format(time.RFC3339),
+			editedAt,
+			strconv.Itoa(t.EditCount),
+		})
+	}
+	return rows
+}
+
+func followRows(follows []follow.Follow) [][]string {
+	rows := make([][]string, 0, len(follows))
+	for _, f := range follows {
+		rows = append(rows, []string{
+			strconv.Itoa(f.FollowerID),
+			strconv.Itoa(f.FollowedID),
+			f.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return rows
+}
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTTL is how long tokens are valid when no other lifetime is
+// configured.
+const DefaultTTL = 30 * 24 * time.Hour
+
+var (
+	// ErrInvalidToken is returned for tokens that are malformed or were not
+	// signed with the signer's secret.
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrExpired is returned for well-signed tokens past their expiry.
+	ErrExpired = errors.New("token expired")
+)
+
+// Signer issues and verifies the bearer tokens that identify the user
+// performing a request. A token is "<user id>.<expiry>.<signature>", where
+// the signature is an HMAC-SHA256 of the first two parts.
+type Signer struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+func NewSigner(secret []byte, ttl time.Duration) *Signer {
+	return &Signer{secret: secret, ttl: ttl, now: time.Now}
+}
+
+// Issue returns a token for userID and the time it expires.
+func (s *Signer) Issue(userID int) (string, time.Time) {
+	expires := s.now().Add(s.ttl).Truncate(time.Second)
+	payload := strconv.Itoa(userID) + "." + strconv.FormatInt(expires.Unix(), 10)
+	return payload + "." + s.sign(payload), expires
+}
+
+// Verify returns the user a token was issued to.
+func (s *Signer) Verify(token string) (int, error) {
+	i := strings.LastIndexByte(token, '.')
+	if i < 0 {
+		return 0, ErrInvalidToken
+	}
+	payload, signature := token[:i], token[i+1:]
+	if !hmac.Equal([]byte(signature), []byte(s.sign(payload))) {
+		return 0, ErrInvalidToken
+	}
+
+	parts := strings.Split(payload, ".")
+	if len(parts) != 2 {
+		return 0, ErrInvalidToken
+	}
+	userID, err1 := strconv.Atoi(parts[0])
+	expires, err2 := strconv.ParseInt(parts[1], 10, 64)
+	if err1 != nil || err2 != nil || userID <= 0 {
+		return 0, ErrInvalidToken
+	}
+	if s.now().Unix() >= expires {
+		return 0, ErrExpired
+	}
+	return userID, nil
+}
+
+func (s *Signer) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
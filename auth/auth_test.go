@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignerRoundTrip(t *testing.T) {
+	now := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	s := NewSigner([]byte("secret"), time.Hour)
+	s.now = func() time.Time { return now }
+
+	token, expires := s.Issue(42)
+	if want := now.Add(time.Hour); !expires.Equal(want) {
+		t.Fatalf("expires = %v, want %v", expires, want)
+	}
+	id, err := s.Verify(token)
+	if err != nil || id != 42 {
+		t.Fatalf("Verify = %d, %v; want 42, nil", id, err)
+	}
+}
+
+func TestSignerRejects(t *testing.T) {
+	now := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	s := NewSigner([]byte("secret"), time.Hour)
+	s.now = func() time.Time { return now }
+	token, _ := s.Issue(42)
+
+	other := NewSigner([]byte("other"), time.Hour)
+	other.now = s.now
+	forged, _ := other.Issue(1)
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"empty", "", ErrInvalidToken},
+		{"no signature", "42.1704211445", ErrInvalidToken},
+		{"other user", "1" + token[2:], ErrInvalidToken},
+		{"other secret", forged, ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		if _, err := s.Verify(tt.token); err != tt.want {
+			t.Errorf("%s: Verify = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	s.now = func() time.Time { return now.Add(time.Hour) }
+	if _, err := s.Verify(token); err != ErrExpired {
+		t.Errorf("expired: Verify = %v, want %v", err, ErrExpired)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"ualabackend/auth"
+	"ualabackend/entities/user"
+	"ualabackend/importer"
+	userRepo "ualabackend/repositories/user"
+	"ualabackend/search"
+)
+
+// runCommand executes one of the maintenance commands that can be passed as
+// the first argument to the binary instead of starting the API.
+func runCommand(database *sql.DB, name string, args []string) error {
+	switch name {
+	case "repair-counters":
+		return repairCounters(database)
+	case "import":
+		return importFile(database, args)
+	case "set-role":
+		return setRole(database, args)
+	case "issue-token":
+		return issueToken(database, args)
+	default:
+		return fmt.Errorf("unknown command %q", name)
+	}
+}
+
+// repairCounters recomputes the follower, following and tweet counters of
+// every user from the source tables.
+func repairCounters(database *sql.DB) error {
+	users := userRepo.NewRepository(database, search.NewNameIndex())
+	updated, err := users.RecomputeCounters()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ Counters recomputed (%d users updated)\n", updated)
+	return nil
+}
+
+// importFile bulk imports users, follows and tweets from an NDJSON or CSV
+// file. The format is taken from the file extension.
+func importFile(database *sql.DB, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: import <file.ndjson|file.csv>")
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	format := importer.FormatNDJSON
+	if strings.EqualFold(filepath.Ext(args[0]), ".csv") {
+		format = importer.FormatCSV
+	}
+
+	users := userRepo.NewRepository(database, search.NewNameIndex())
+	report, err := importer.New(database, users).Run(f, format)
+	if report != nil {
+		for _, e := range report.Errors {
+			fmt.Printf("⚠️ line %d: %s\n", e.Line, e.Error)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ Imported %d users, %d follows and %d tweets (%d rows skipped)\n",
+		report.Users, report.Follows, report.Tweets, len(report.Errors))
+	return nil
+}
+
+// setRole assigns a role to a user. It is how the first administrator is
+// created; after that roles can be changed through the admin API.
+func setRole(database *sql.DB, args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: set-role <user id> <user|moderator|admin>")
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid user ID %q", args[0])
+	}
+	if !user.ValidRole(args[1]) {
+		return fmt.Errorf("invalid role %q", args[1])
+	}
+
+	users := userRepo.NewRepository(database, search.NewNameIndex())
+	u, err := users.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if u == nil {
+		return fmt.Errorf("user %d not found", id)
+	}
+	if err := users.SetRole(id, args[1]); err != nil {
+		return err
+	}
+	fmt.Printf("✅ User %d is now %s\n", id, args[1])
+	return nil
+}
+
+// issueToken prints an access token for a user, signed with AUTH_SECRET so
+// the API accepts it.
+func issueToken(database *sql.DB, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: issue-token <user id>")
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid user ID %q", args[0])
+	}
+	secret := os.Getenv("AUTH_SECRET")
+	if secret == "" {
+		return errors.New("AUTH_SECRET is not set")
+	}
+	ttl := auth.DefaultTTL
+	if v := os.Getenv("AUTH_TOKEN_TTL"); v != "" {
+		if ttl, err = time.ParseDuration(v); err != nil {
+			return fmt.Errorf("invalid AUTH_TOKEN_TTL %q", v)
+		}
+	}
+
+	users := userRepo.NewRepository(database, search.NewNameIndex())
+	u, err := users.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if u == nil {
+		return fmt.Errorf("user %d not found", id)
+	}
+	token, expires := auth.NewSigner([]byte(secret), ttl).Issue(id)
+	fmt.Printf("%s\n(expires %s)\n", token, expires.Format(time.RFC3339))
+	return nil
+}
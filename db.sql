@@ -1,3 +1,31 @@
+DROP TABLE IF EXISTS audit_log;
+DROP TABLE IF EXISTS moderation_actions;
+DROP TABLE IF EXISTS reports;
+DROP TABLE IF EXISTS trend_checkpoint;
+DROP TABLE IF EXISTS trend_candidates;
+DROP TABLE IF EXISTS trend_buckets;
+DROP TABLE IF EXISTS tweet_links;
+DROP TABLE IF EXISTS links;
+DROP TABLE IF EXISTS media;
+DROP TABLE IF EXISTS poll_votes;
+DROP TABLE IF EXISTS poll_options;
+DROP TABLE IF EXISTS polls;
+DROP TABLE IF EXISTS drafts;
+DROP TABLE IF EXISTS scheduled_tweets;
+DROP TABLE IF EXISTS imported_tweets;
+DROP TABLE IF EXISTS imported_users;
+DROP TABLE IF EXISTS export_jobs;
+DROP TABLE IF EXISTS tweet_revisions;
+DROP TABLE IF EXISTS list_subscribers;
+DROP TABLE IF EXISTS list_members;
+DROP TABLE IF EXISTS lists;
+DROP TABLE IF EXISTS message_deletions;
+DROP TABLE IF EXISTS messages;
+DROP TABLE IF EXISTS conversation_participants;
+DROP TABLE IF EXISTS conversations;
+DROP TABLE IF EXISTS follow_requests;
+DROP TABLE IF EXISTS mutes;
+DROP TABLE IF EXISTS blocks;
 DROP TABLE IF EXISTS follows;
 DROP TABLE IF EXISTS tweets;
 DROP TABLE IF EXISTS users;
@@ -7,7 +35,16 @@ CREATE TABLE users (
     name VARCHAR(100) NOT NULL,
     followers_id JSON,
     following_id JSON,
-    feed JSON
+    feed JSON,
+    protected BOOLEAN NOT NULL DEFAULT FALSE,
+    allow_dms_from_anyone BOOLEAN NOT NULL DEFAULT FALSE,
+    role VARCHAR(16) NOT NULL DEFAULT 'user',
+    followers_count INT NOT NULL DEFAULT 0,
+    following_count INT NOT NULL DEFAULT 0,
+    tweet_count INT NOT NULL DEFAULT 0,
+    deactivated_at DATETIME NULL,
+    suspended_at DATETIME NULL,
+    reach_restricted BOOLEAN NOT NULL DEFAULT FALSE
 );
 
 CREATE TABLE tweets (
@@ -15,14 +52,300 @@ CREATE TABLE tweets (
     message TEXT NOT NULL,
     timestamp DATETIME NOT NULL,
     author_id BIGINT NOT NULL,
-    FOREIGN KEY (author_id) REFERENCES users(id)
+    edited_at DATETIME NULL,
+    edit_count INT NOT NULL DEFAULT 0,
+    deleted_at DATETIME NULL,
+    hidden_at DATETIME NULL,
+    labels JSON NULL,
+    FOREIGN KEY (author_id) REFERENCES users(id),
+    INDEX idx_tweets_deleted (deleted_at)
+);
+
+CREATE TABLE tweet_revisions (
+    tweet_id BIGINT NOT NULL,
+    revision INT NOT NULL,
+    message TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (tweet_id, revision),
+    FOREIGN KEY (tweet_id) REFERENCES tweets(id) ON DELETE CASCADE
 );
 
 CREATE TABLE follows (
     id BIGINT PRIMARY KEY AUTO_INCREMENT,
     follower_id BIGINT NOT NULL,
     followed_id BIGINT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (follower_id) REFERENCES users(id),
     FOREIGN KEY (followed_id) REFERENCES users(id),
-    UNIQUE (follower_id, followed_id)
+    UNIQUE (follower_id, followed_id),
+    INDEX idx_follows_followed (followed_id, created_at)
+);
+
+CREATE TABLE blocks (
+    blocker_id BIGINT NOT NULL,
+    blocked_id BIGINT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (blocker_id, blocked_id),
+    FOREIGN KEY (blocker_id) REFERENCES users(id),
+    FOREIGN KEY (blocked_id) REFERENCES users(id)
+);
+
+CREATE TABLE mutes (
+    muter_id BIGINT NOT NULL,
+    muted_id BIGINT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (muter_id, muted_id),
+    FOREIGN KEY (muter_id) REFERENCES users(id),
+    FOREIGN KEY (muted_id) REFERENCES users(id)
+);
+
+CREATE TABLE follow_requests (
+    requester_id BIGINT NOT NULL,
+    target_id BIGINT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (requester_id, target_id),
+    FOREIGN KEY (requester_id) REFERENCES users(id),
+    FOREIGN KEY (target_id) REFERENCES users(id)
+);
+
+CREATE TABLE conversations (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    is_group BOOLEAN NOT NULL DEFAULT FALSE,
+    -- "<lower user id>:<higher user id>" for 1:1 conversations, NULL for
+    -- groups, so there is at most one conversation per pair.
+    pair_key VARCHAR(41) NULL UNIQUE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE conversation_participants (
+    conversation_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    last_read_message_id BIGINT NOT NULL DEFAULT 0,
+    cleared_before_message_id BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (conversation_id, user_id),
+    FOREIGN KEY (conversation_id) REFERENCES conversations(id),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    INDEX idx_participants_user (user_id)
+);
+
+CREATE TABLE messages (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    conversation_id BIGINT NOT NULL,
+    sender_id BIGINT NOT NULL,
+    body TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (conversation_id) REFERENCES conversations(id),
+    FOREIGN KEY (sender_id) REFERENCES users(id),
+    INDEX idx_messages_conversation (conversation_id, id)
+);
+
+CREATE TABLE message_deletions (
+    message_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    PRIMARY KEY (message_id, user_id),
+    FOREIGN KEY (message_id) REFERENCES messages(id),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE TABLE lists (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    owner_id BIGINT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    private BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (owner_id) REFERENCES users(id)
+);
+
+CREATE TABLE list_members (
+    list_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (list_id, user_id),
+    FOREIGN KEY (list_id) REFERENCES lists(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE TABLE list_subscribers (
+    list_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (list_id, user_id),
+    FOREIGN KEY (list_id) REFERENCES lists(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE TABLE export_jobs (
+    id CHAR(32) PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    error TEXT NOT NULL DEFAULT (''),
+    file_path VARCHAR(255) NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL,
+    completed_at DATETIME NULL,
+    expires_at DATETIME NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    INDEX idx_export_jobs_status (status, expires_at)
+);
+
+CREATE TABLE imported_users (
+    external_id VARCHAR(255) PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE TABLE imported_tweets (
+    external_id VARCHAR(255) PRIMARY KEY,
+    tweet_id BIGINT NOT NULL,
+    FOREIGN KEY (tweet_id) REFERENCES tweets(id) ON DELETE CASCADE
+);
+
+CREATE TABLE scheduled_tweets (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    author_id BIGINT NOT NULL,
+    message TEXT NOT NULL,
+    publish_at DATETIME NOT NULL,
+    created_at DATETIME NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    error TEXT NOT NULL DEFAULT (''),
+    FOREIGN KEY (author_id) REFERENCES users(id),
+    INDEX idx_scheduled_tweets_publish (status, publish_at)
+);
+
+CREATE TABLE drafts (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    message TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    INDEX idx_drafts_user (user_id, updated_at)
+);
+
+CREATE TABLE polls (
+    tweet_id BIGINT PRIMARY KEY,
+    closes_at DATETIME NOT NULL,
+    results_visibility VARCHAR(16) NOT NULL,
+    FOREIGN KEY (tweet_id) REFERENCES tweets(id) ON DELETE CASCADE
+);
+
+CREATE TABLE poll_options (
+    tweet_id BIGINT NOT NULL,
+    position INT NOT NULL,
+    label VARCHAR(25) NOT NULL,
+    votes INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (tweet_id, position),
+    FOREIGN KEY (tweet_id) REFERENCES polls(tweet_id) ON DELETE CASCADE
+);
+
+CREATE TABLE poll_votes (
+    tweet_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    position INT NOT NULL,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (tweet_id, user_id),
+    FOREIGN KEY (tweet_id, position) REFERENCES poll_options(tweet_id, position) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE TABLE media (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    owner_id BIGINT NULL,
+    tweet_id BIGINT NULL,
+    position INT NULL,
+    kind VARCHAR(16) NOT NULL,
+    mime_type VARCHAR(64) NOT NULL,
+    size BIGINT NOT NULL,
+    width INT NOT NULL DEFAULT 0,
+    height INT NOT NULL DEFAULT 0,
+    alt_text VARCHAR(1000) NOT NULL DEFAULT '',
+    blob_key VARCHAR(255) NOT NULL,
+    thumbnail_key VARCHAR(255) NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (owner_id) REFERENCES users(id),
+    FOREIGN KEY (tweet_id) REFERENCES tweets(id) ON DELETE SET NULL,
+    INDEX idx_media_tweet (tweet_id, position)
+);
+
+CREATE TABLE links (
+    code VARCHAR(16) PRIMARY KEY,
+    url TEXT NOT NULL,
+    url_hash CHAR(64) NOT NULL,
+    clicks BIGINT NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL,
+    INDEX idx_links_url_hash (url_hash)
+);
+
+CREATE TABLE tweet_links (
+    tweet_id BIGINT NOT NULL,
+    position INT NOT NULL,
+    code VARCHAR(16) NOT NULL,
+    start_index INT NOT NULL,
+    end_index INT NOT NULL,
+    PRIMARY KEY (tweet_id, position),
+    FOREIGN KEY (tweet_id) REFERENCES tweets(id) ON DELETE CASCADE,
+    FOREIGN KEY (code) REFERENCES links(code)
+);
+
+CREATE TABLE trend_buckets (
+    start DATETIME PRIMARY KEY,
+    data MEDIUMBLOB NOT NULL
+);
+
+CREATE TABLE trend_candidates (
+    term VARCHAR(191) PRIMARY KEY,
+    last_seen DATETIME NOT NULL
+);
+
+CREATE TABLE trend_checkpoint (
+    id TINYINT PRIMARY KEY,
+    taken_at DATETIME NOT NULL
+);
+
+CREATE TABLE reports (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    reporter_id BIGINT NULL,
+    target_type VARCHAR(16) NOT NULL,
+    target_id BIGINT NOT NULL,
+    reason VARCHAR(32) NOT NULL,
+    details VARCHAR(1000) NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL,
+    created_at DATETIME NOT NULL,
+    resolved_at DATETIME NULL,
+    FOREIGN KEY (reporter_id) REFERENCES users(id),
+    INDEX idx_reports_status (status, created_at),
+    INDEX idx_reports_target (target_type, target_id)
 );
+
+CREATE TABLE moderation_actions (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    moderator_id BIGINT NOT NULL,
+    report_id BIGINT NULL,
+    action VARCHAR(32) NOT NULL,
+    target_type VARCHAR(16) NOT NULL,
+    target_id BIGINT NOT NULL,
+    note VARCHAR(1000) NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL,
+    INDEX idx_moderation_actions_target (target_type, target_id, id)
+);
+
+CREATE TABLE audit_log (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    actor_id BIGINT NULL,
+    action VARCHAR(32) NOT NULL,
+    target_type VARCHAR(16) NOT NULL,
+    target_id BIGINT NOT NULL,
+    diff JSON NOT NULL,
+    request_id VARCHAR(64) NOT NULL,
+    ip VARCHAR(45) NOT NULL,
+    created_at DATETIME(3) NOT NULL,
+    INDEX idx_audit_log_actor (actor_id, id),
+    INDEX idx_audit_log_target (target_type, target_id, id),
+    INDEX idx_audit_log_action (action, id)
+);
+
+CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log FOR EACH ROW
+    SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_log is append-only';
+
+CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log FOR EACH ROW
+    SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_log is append-only';
@@ -0,0 +1,19 @@
+package admin
+
+import "time"
+
+// Stats is a snapshot of the size of the system, shown to administrators.
+type Stats struct {
+	Users            int       `json:"users" example:"120"`
+	ActiveUsers      int       `json:"active_users" example:"110"`
+	SuspendedUsers   int       `json:"suspended_users" example:"4"`
+	DeactivatedUsers int       `json:"deactivated_users" example:"6"`
+	Tweets           int       `json:"tweets" example:"3400"`
+	DeletedTweets    int       `json:"deleted_tweets" example:"25"`
+	HiddenTweets     int       `json:"hidden_tweets" example:"3"`
+	ScheduledTweets  int       `json:"scheduled_tweets" example:"12"`
+	Follows          int       `json:"follows" example:"980"`
+	Media            int       `json:"media" example:"210"`
+	OpenReports      int       `json:"open_reports" example:"7"`
+	GeneratedAt      time.Time `json:"generated_at" example:"2024-01-02T15:04:05Z"`
+}
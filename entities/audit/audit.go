@@ -0,0 +1,126 @@
+package audit
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+const (
+	TargetUser           = "user"
+	TargetTweet          = "tweet"
+	TargetScheduledTweet = "scheduled_tweet"
+	// TargetTimelines is the target of operations on every user's timeline
+	// at once; its target ID is always 0.
+	TargetTimelines = "timelines"
+
+	ActionUserCreate     = "user.create"
+	ActionUserUpdate     = "user.update"
+	ActionUserDeactivate = "user.deactivate"
+	ActionUserReactivate = "user.reactivate"
+	ActionUserProtect    = "user.set_protected"
+	ActionUserSetRole    = "user.set_role"
+	ActionUserSuspend    = "user.suspend"
+	ActionUserUnsuspend  = "user.unsuspend"
+	ActionUserSetDMs     = "user.set_dm_settings"
+	ActionUserImport     = "user.import"
+
+	ActionUserRestrictReach   = "user.restrict_reach"
+	ActionUserUnrestrictReach = "user.unrestrict_reach"
+
+	ActionTweetCreate      = "tweet.create"
+	ActionTweetSchedule    = "tweet.schedule"
+	ActionTweetUpdate      = "tweet.update"
+	ActionTweetDelete      = "tweet.delete"
+	ActionTweetRestore     = "tweet.restore"
+	ActionTweetForceDelete = "tweet.force_delete"
+	ActionTweetReschedule  = "tweet.reschedule"
+	ActionTweetCancel      = "tweet.cancel_schedule"
+	ActionTweetPublish     = "tweet.publish_draft"
+	ActionTweetHide        = "tweet.hide"
+	ActionTweetUnhide      = "tweet.unhide"
+	ActionTweetImport      = "tweet.import"
+
+	ActionTimelinesRebuild = "timelines.rebuild"
+
+	ActionFollowCreate  = "follow.create"
+	ActionFollowRequest = "follow.request"
+	ActionFollowDelete  = "follow.delete"
+	ActionFollowApprove = "follow.approve"
+	ActionFollowReject  = "follow.reject"
+	ActionFollowImport  = "follow.import"
+)
+
+// Entry records a state-changing operation. ActorID is the user that
+// performed it, or 0 when the request did not identify its user. Follow
+// operations target the followed user.
+type Entry struct {
+	Id         int               `json:"id" example:"1"`
+	ActorID    int               `json:"actor_id,omitempty" example:"2"`
+	Action     string            `json:"action" example:"tweet.update"`
+	TargetType string            `json:"target_type" example:"tweet"`
+	TargetID   int               `json:"target_id" example:"10"`
+	Diff       map[string]Change `json:"diff"`
+	RequestID  string            `json:"request_id" example:"9f86d081884c7d65"`
+	IP         string            `json:"ip" example:"203.0.113.7"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+// Change is the value of a field before and after an operation. A null side
+// means the field did not exist, as when something is created or deleted.
+type Change struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// Filter narrows a query of the audit log. Zero values match everything.
+type Filter struct {
+	ActorID    int
+	Action     string
+	TargetType string
+	TargetID   int
+	Since      time.Time
+	Until      time.Time
+}
+
+// Diff returns the fields whose value differs between before and after,
+// which must encode as JSON objects. A nil side counts as an object with no
+// fields.
+func Diff(before, after interface{}) (map[string]Change, error) {
+	b, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := map[string]Change{}
+	for k, v := range b {
+		if !reflect.DeepEqual(v, a[k]) {
+			diff[k] = Change{Before: v, After: a[k]}
+		}
+	}
+	for k, v := range a {
+		if _, ok := b[k]; !ok {
+			diff[k] = Change{After: v}
+		}
+	}
+	return diff, nil
+}
+
+func fields(v interface{}) (map[string]interface{}, error) {
+	m := map[string]interface{}{}
+	if v == nil || reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil() {
+		return m, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,19 @@
+package block
+
+import "time"
+
+type Block struct {
+	BlockerID int       `json:"blocker_id"`
+	BlockedID int       `json:"blocked_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Mute struct {
+	MuterID   int       `json:"muter_id"`
+	MutedID   int       `json:"muted_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TargetInput struct {
+	TargetID int `json:"target_id" example:"2" binding:"required"`
+}
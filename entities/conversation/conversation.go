@@ -0,0 +1,32 @@
+package conversation
+
+import "time"
+
+type Conversation struct {
+	Id             int       `json:"id" example:"1"`
+	IsGroup        bool      `json:"is_group" example:"false"`
+	ParticipantIDs []int     `json:"participant_ids" example:"1,2"`
+	CreatedAt      time.Time `json:"created_at"`
+	UnreadCount    int       `json:"unread_count" example:"0"`
+}
+
+type Message struct {
+	Id             int       `json:"id" example:"1"`
+	ConversationID int       `json:"conversation_id" example:"1"`
+	SenderID       int       `json:"sender_id" example:"1"`
+	Body           string    `json:"body" example:"Hola!"`
+	CreatedAt      time.Time `json:"created_at"`
+	ReadBy         []int     `json:"read_by" example:"2"`
+}
+
+type ConversationInput struct {
+	ParticipantIDs []int `json:"participant_ids" example:"2" binding:"required,min=1"`
+}
+
+type MessageInput struct {
+	Body string `json:"body" example:"Hola!" binding:"required"`
+}
+
+type DMSettingsInput struct {
+	AllowFromAnyone *bool `json:"allow_dms_from_anyone" example:"true" binding:"required"`
+}
@@ -0,0 +1,16 @@
+package draft
+
+import "time"
+
+// Draft is an unpublished tweet, only visible to its owner.
+type Draft struct {
+	Id        int       `json:"id" example:"1"`
+	UserID    int       `json:"user_id" example:"1"`
+	Message   string    `json:"message" example:"Hola mun"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type DraftInput struct {
+	Message string `json:"message" example:"Hola mun" binding:"required"`
+}
@@ -0,0 +1,23 @@
+package export
+
+import "time"
+
+const (
+	StatusPending = "pending"
+	StatusRunning = "running"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+	StatusExpired = "expired"
+)
+
+// Job tracks the asynchronous build of a user's data archive.
+type Job struct {
+	Id          string     `json:"id" example:"9f86d081884c7d65"`
+	UserID      int        `json:"user_id" example:"1"`
+	Status      string     `json:"status" example:"pending"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	FilePath    string     `json:"-"`
+}
@@ -1,16 +1,60 @@
 package follow
 
 import (
+	"encoding/json"
 	"time"
+
+	"ualabackend/entities/user"
 )
 
 type Follow struct {
 	FollowerID int       `json:"follower_id"`
 	FollowedID int       `json:"followed_id"`
-	Timestamp  time.Time `json:"timestamp"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// MarshalJSON also sends CreatedAt as "timestamp", the name it had before
+// follows stored their creation time, so existing clients keep working.
+func (f Follow) MarshalJSON() ([]byte, error) {
+	type plain Follow
+	return json.Marshal(struct {
+		plain
+		Timestamp time.Time `json:"timestamp"`
+	}{plain(f), f.CreatedAt})
 }
 
 type FollowInput struct {
 	FollowerID int `json:"follower_id" example:"1"`
 	FollowedID int `json:"followed_id" example:"2"`
 }
+
+// FollowRequest is a pending follow of a protected account.
+type FollowRequest struct {
+	RequesterID   int       `json:"requester_id" example:"1"`
+	RequesterName string    `json:"requester_name" example:"John Doe"`
+	TargetID      int       `json:"target_id" example:"2"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Relationship describes how two users relate to each other, seen from Source.
+type Relationship struct {
+	SourceID   int  `json:"source_id" example:"1"`
+	TargetID   int  `json:"target_id" example:"2"`
+	Following  bool `json:"following" example:"true"`
+	FollowedBy bool `json:"followed_by" example:"true"`
+	Mutual     bool `json:"mutual" example:"true"`
+	Blocking   bool `json:"blocking" example:"false"`
+	BlockedBy  bool `json:"blocked_by" example:"false"`
+}
+
+// Suggestion is an account recommended to a user, with the reason for it.
+type Suggestion struct {
+	User        user.UserSummary `json:"user"`
+	MutualCount int              `json:"mutual_count" example:"3"`
+	Reason      string           `json:"reason" example:"friends_of_friends"`
+}
+
+const (
+	ReasonFriendsOfFriends = "friends_of_friends"
+	ReasonPopular          = "popular"
+)
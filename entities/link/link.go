@@ -0,0 +1,10 @@
+package link
+
+// Link is a URL found in a tweet. URL is the short link that replaced it in
+// the message; Indices are the rune offsets of the short link in the message.
+type Link struct {
+	URL         string `json:"url" example:"http://localhost:9090/l/aZ3kQ9x"`
+	ExpandedURL string `json:"expanded_url" example:"https://example.com/articulo"`
+	DisplayURL  string `json:"display_url" example:"example.com/articulo"`
+	Indices     [2]int `json:"indices" example:"6,37"`
+}
@@ -0,0 +1,24 @@
+package list
+
+import "time"
+
+type List struct {
+	Id              int       `json:"id" example:"1"`
+	OwnerID         int       `json:"owner_id" example:"1"`
+	Name            string    `json:"name" example:"Amigos"`
+	Description     string    `json:"description" example:"Gente que sigo de cerca"`
+	Private         bool      `json:"private" example:"false"`
+	MemberCount     int       `json:"member_count" example:"5"`
+	SubscriberCount int       `json:"subscriber_count" example:"2"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type ListInput struct {
+	Name        string `json:"name" example:"Amigos" binding:"required,max=100"`
+	Description string `json:"description" example:"Gente que sigo de cerca" binding:"max=255"`
+	Private     bool   `json:"private" example:"false"`
+}
+
+type MemberInput struct {
+	UserID int `json:"user_id" example:"2" binding:"required"`
+}
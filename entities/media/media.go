@@ -0,0 +1,26 @@
+package media
+
+import "time"
+
+// Media is an uploaded image or video. Blob keys are internal; clients fetch
+// the content through URL and ThumbnailURL.
+type Media struct {
+	Id           int       `json:"id" example:"1"`
+	OwnerID      int       `json:"owner_id" example:"1"`
+	TweetID      *int      `json:"tweet_id,omitempty" example:"10"`
+	Kind         string    `json:"kind" example:"image"`
+	MimeType     string    `json:"mime_type" example:"image/png"`
+	Size         int64     `json:"size" example:"204800"`
+	Width        int       `json:"width,omitempty" example:"1024"`
+	Height       int       `json:"height,omitempty" example:"768"`
+	AltText      string    `json:"alt_text" example:"Un gato durmiendo"`
+	URL          string    `json:"url" example:"/media/1/file"`
+	ThumbnailURL string    `json:"thumbnail_url,omitempty" example:"/media/1/thumbnail"`
+	CreatedAt    time.Time `json:"created_at"`
+	BlobKey      string    `json:"-"`
+	ThumbnailKey string    `json:"-"`
+}
+
+type AltTextInput struct {
+	AltText string `json:"alt_text" example:"Un gato durmiendo"`
+}
@@ -0,0 +1,87 @@
+package moderation
+
+import "time"
+
+const (
+	TargetTweet = "tweet"
+	TargetUser  = "user"
+
+	StatusOpen      = "open"
+	StatusResolved  = "resolved"
+	StatusDismissed = "dismissed"
+
+	ActionHideTweet       = "hide_tweet"
+	ActionUnhideTweet     = "unhide_tweet"
+	ActionSuspendUser     = "suspend_user"
+	ActionUnsuspendUser   = "unsuspend_user"
+	ActionRestrictReach   = "restrict_reach"
+	ActionUnrestrictReach = "unrestrict_reach"
+	ActionDismiss         = "dismiss"
+)
+
+// ReasonContentFilter is the reason of the reports filed for tweets held by
+// the content filter.
+const ReasonContentFilter = "content_filter"
+
+// Reasons lists the reasons users may give when reporting.
+var Reasons = []string{"spam", "abuse", "harassment", "hate", "violence", "impersonation", "other"}
+
+// Report flags a tweet or a user for review by a moderator. ReporterID is 0
+// for reports filed by the content filter.
+type Report struct {
+	Id         int        `json:"id" example:"1"`
+	ReporterID int        `json:"reporter_id,omitempty" example:"2"`
+	TargetType string     `json:"target_type" example:"tweet"`
+	TargetID   int        `json:"target_id" example:"10"`
+	Reason     string     `json:"reason" example:"spam"`
+	Details    string     `json:"details,omitempty" example:"Publica el mismo link una y otra vez"`
+	Status     string     `json:"status" example:"open"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
+
+type ReportInput struct {
+	TargetType string `json:"target_type" example:"tweet" binding:"required"`
+	TargetID   int    `json:"target_id" example:"10" binding:"required"`
+	Reason     string `json:"reason" example:"spam" binding:"required"`
+	Details    string `json:"details" example:"Publica el mismo link una y otra vez"`
+}
+
+// Action is an entry of the audit trail: a decision made by a moderator,
+// either on a report or directly on a target.
+type Action struct {
+	Id          int       `json:"id" example:"1"`
+	ModeratorID int       `json:"moderator_id" example:"1"`
+	ReportID    *int      `json:"report_id,omitempty" example:"1"`
+	Action      string    `json:"action" example:"hide_tweet"`
+	TargetType  string    `json:"target_type" example:"tweet"`
+	TargetID    int       `json:"target_id" example:"10"`
+	Note        string    `json:"note,omitempty" example:"Spam reiterado"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// DecisionInput resolves a report, applying Action to its target.
+type DecisionInput struct {
+	Action string `json:"action" example:"hide_tweet" binding:"required"`
+	Note   string `json:"note" example:"Spam reiterado"`
+}
+
+// ActionInput applies or reverts an enforcement action without a report.
+type ActionInput struct {
+	Action     string `json:"action" example:"unsuspend_user" binding:"required"`
+	TargetType string `json:"target_type" example:"user" binding:"required"`
+	TargetID   int    `json:"target_id" example:"3" binding:"required"`
+	Note       string `json:"note" example:"Apelación aceptada"`
+}
+
+// TargetOf returns the type of target an action applies to, or "" for an
+// unknown action. ActionDismiss applies to any target.
+func TargetOf(action string) string {
+	switch action {
+	case ActionHideTweet, ActionUnhideTweet:
+		return TargetTweet
+	case ActionSuspendUser, ActionUnsuspendUser, ActionRestrictReach, ActionUnrestrictReach:
+		return TargetUser
+	}
+	return ""
+}
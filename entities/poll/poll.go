@@ -0,0 +1,44 @@
+package poll
+
+import "time"
+
+const (
+	MinOptions = 2
+	MaxOptions = 4
+
+	MinDuration = 5 * time.Minute
+	MaxDuration = 7 * 24 * time.Hour
+
+	// ResultsAfterVote shows the tallies to viewers who already voted and to
+	// everyone once the poll closes.
+	ResultsAfterVote = "after_vote"
+	// ResultsAfterClose hides the tallies from everyone until the poll closes.
+	ResultsAfterClose = "after_close"
+)
+
+// Poll is attached to a tweet. Votes and TotalVotes are nil while the
+// tallies are hidden from the viewer.
+type Poll struct {
+	Options           []Option  `json:"options"`
+	ResultsVisibility string    `json:"results_visibility" example:"after_vote"`
+	ClosesAt          time.Time `json:"closes_at"`
+	Closed            bool      `json:"closed" example:"false"`
+	TotalVotes        *int      `json:"total_votes,omitempty" example:"12"`
+	ViewerVote        *int      `json:"viewer_vote,omitempty" example:"1"`
+}
+
+type Option struct {
+	Position int    `json:"position" example:"1"`
+	Label    string `json:"label" example:"Sí"`
+	Votes    *int   `json:"votes,omitempty" example:"7"`
+}
+
+type PollInput struct {
+	Options           []string `json:"options" example:"Sí,No"`
+	DurationMinutes   int      `json:"duration_minutes" example:"1440"`
+	ResultsVisibility string   `json:"results_visibility,omitempty" example:"after_vote"`
+}
+
+type VoteInput struct {
+	Option int `json:"option" example:"1" binding:"required"`
+}
@@ -2,6 +2,10 @@ package tweet
 
 import (
 	"time"
+
+	"ualabackend/entities/link"
+	"ualabackend/entities/media"
+	"ualabackend/entities/poll"
 )
 
 type Tweet struct {
@@ -9,10 +13,54 @@ type Tweet struct {
 	Timestamp time.Time
 	Message   string
 	Author_id int
+	EditedAt  *time.Time
+	EditCount int
+	Poll      *poll.Poll    `json:",omitempty"`
+	Media     []media.Media `json:",omitempty"`
+	Links     []link.Link   `json:",omitempty"`
+	// Labels are attached by the content filter.
+	Labels []string `json:",omitempty"`
+}
+
+// Revision is an immutable version of a tweet's text.
+type Revision struct {
+	TweetID   int       `json:"tweet_id" example:"1"`
+	Revision  int       `json:"revision" example:"1"`
+	Message   string    `json:"message" example:"Hola mundo"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 
+const (
+	ScheduledPending = "pending"
+	ScheduledFailed  = "failed"
+)
+
+// ScheduledTweet is a tweet waiting to be published at PublishAt. A tweet
+// that could not be published stays with status failed and the reason in
+// Error until its author cancels it.
+type ScheduledTweet struct {
+	Id        int       `json:"id" example:"1"`
+	AuthorID  int       `json:"author_id" example:"1"`
+	Message   string    `json:"message" example:"Hola mundo"`
+	PublishAt time.Time `json:"publish_at"`
+	CreatedAt time.Time `json:"created_at"`
+	Status    string    `json:"status" example:"pending"`
+	Error     string    `json:"error,omitempty"`
+}
+
 type TweetInput struct {
 	Message  string `json:"message" example:"Hola mundo" binding:"required"`
-	AuthorID int    `json:"author_id" example:"1" binding:"required"`
+	// AuthorID is optional and must match the authenticated user.
+	AuthorID int    `json:"author_id" example:"1"`
+	// PublishAt schedules the tweet instead of publishing it right away.
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	// Poll optionally attaches a poll to the tweet.
+	Poll *poll.PollInput `json:"poll,omitempty"`
+	// MediaIDs references up to four media uploaded by the author.
+	MediaIDs []int `json:"media_ids,omitempty" example:"1,2"`
+}
+
+type ScheduleInput struct {
+	PublishAt *time.Time `json:"publish_at" binding:"required"`
 }
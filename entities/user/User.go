@@ -1,6 +1,29 @@
 package user
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// roleLevels orders the roles: each one is granted everything the ones
+// below it are.
+var roleLevels = map[string]int{RoleUser: 1, RoleModerator: 2, RoleAdmin: 3}
+
+// ValidRole reports whether role is one of the known roles.
+func ValidRole(role string) bool {
+	return roleLevels[role] > 0
+}
+
+// HasRole reports whether a user with role is granted required.
+func HasRole(role, required string) bool {
+	return roleLevels[role] >= roleLevels[required] && ValidRole(role)
+}
 
 type User struct {
 	Id           int             `json:"id" example:"1"`
@@ -8,8 +31,57 @@ type User struct {
 	Followers_id json.RawMessage `json:"followers_id" example:"[2, 3, 4]"`
 	Following_id json.RawMessage `json:"following_id" example:"[5, 6]"`
 	Feed         json.RawMessage `json:"feed" example:"[101, 102]"`
+	Protected    bool            `json:"protected" example:"false"`
+	AllowDMs     bool            `json:"allow_dms_from_anyone" example:"false"`
+	Role         string          `json:"role" example:"user"`
+
+	FollowersCount int `json:"followers_count" example:"3"`
+	FollowingCount int `json:"following_count" example:"2"`
+	TweetCount     int `json:"tweet_count" example:"10"`
+
+	DeactivatedAt *time.Time `json:"deactivated_at,omitempty"`
+	SuspendedAt   *time.Time `json:"suspended_at,omitempty"`
+	// ReachRestricted is only known to moderators.
+	ReachRestricted bool `json:"-"`
 }
 
 type UserInput struct {
 	Name string `json:"name" example:"John Doe" binding:"required"`
 }
+
+// Token is a bearer token identifying the user in the Authorization header.
+type Token struct {
+	Token     string    `json:"token" example:"1.1735689600.Zm9vYmFy"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type RoleInput struct {
+	Role string `json:"role" example:"moderator" binding:"required"`
+}
+
+// Account is the view of a user in the admin API, including its moderation
+// state.
+type Account struct {
+	Id              int        `json:"id" example:"1"`
+	Name            string     `json:"name" example:"John Doe"`
+	Role            string     `json:"role" example:"user"`
+	Protected       bool       `json:"protected" example:"false"`
+	FollowersCount  int        `json:"followers_count" example:"3"`
+	FollowingCount  int        `json:"following_count" example:"2"`
+	TweetCount      int        `json:"tweet_count" example:"10"`
+	ReachRestricted bool       `json:"reach_restricted" example:"false"`
+	DeactivatedAt   *time.Time `json:"deactivated_at,omitempty"`
+	SuspendedAt     *time.Time `json:"suspended_at,omitempty"`
+}
+
+type ProtectedInput struct {
+	Protected *bool `json:"protected" example:"true" binding:"required"`
+}
+
+// UserSummary is the compact representation used in search results and lists.
+type UserSummary struct {
+	Id               int    `json:"id" example:"1"`
+	Name             string `json:"name" example:"John Doe"`
+	FollowersCount   int    `json:"followers_count" example:"42"`
+	FollowedByViewer bool   `json:"followed_by_viewer" example:"false"`
+}
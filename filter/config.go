@@ -0,0 +1,107 @@
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Config describes a pipeline. It is read from a JSON file such as:
+//
+//	{
+//	  "word_lists": [
+//	    {"name": "insultos", "words": ["idiota"], "action": "label", "label": "ofensivo"}
+//	  ],
+//	  "link_blocklist": {"domains": ["malware.example"], "action": "reject"},
+//	  "duplicates": {"max": 3, "window": "1h", "action": "hold"}
+//	}
+type Config struct {
+	WordLists     []WordListConfig     `json:"word_lists"`
+	LinkBlocklist *LinkBlocklistConfig `json:"link_blocklist"`
+	Duplicates    *DuplicatesConfig    `json:"duplicates"`
+}
+
+// ActionConfig is what a rule does when it matches. Label defaults to the
+// rule name.
+type ActionConfig struct {
+	Action string `json:"action"`
+	Label  string `json:"label"`
+}
+
+type WordListConfig struct {
+	Name  string   `json:"name"`
+	Words []string `json:"words"`
+	ActionConfig
+}
+
+type LinkBlocklistConfig struct {
+	Domains []string `json:"domains"`
+	ActionConfig
+}
+
+type DuplicatesConfig struct {
+	Max    int    `json:"max"`
+	Window string `json:"window"`
+	ActionConfig
+}
+
+// DefaultConfig rejects a message sent a fourth time by the same author
+// within an hour.
+var DefaultConfig = Config{
+	Duplicates: &DuplicatesConfig{Max: 3, Window: "1h", ActionConfig: ActionConfig{Action: ActionReject}},
+}
+
+// Load reads a Config from the JSON file at path.
+func Load(path string) (Config, error) {
+	var cfg Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("filter: %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Build returns the pipeline described by cfg.
+func (cfg Config) Build() (*Pipeline, error) {
+	var steps []Step
+	add := func(name string, rule Rule, a ActionConfig) error {
+		if _, ok := strength[a.Action]; !ok {
+			return fmt.Errorf("filter: rule %q: unknown action %q", name, a.Action)
+		}
+		label := a.Label
+		if label == "" {
+			label = name
+		}
+		steps = append(steps, Step{Name: name, Rule: rule, Action: a.Action, Label: label})
+		return nil
+	}
+
+	for i, l := range cfg.WordLists {
+		name := l.Name
+		if name == "" {
+			name = fmt.Sprintf("word_list_%d", i+1)
+		}
+		if err := add(name, NewWordList(l.Words), l.ActionConfig); err != nil {
+			return nil, err
+		}
+	}
+	if b := cfg.LinkBlocklist; b != nil {
+		if err := add("link_blocklist", NewLinkBlocklist(b.Domains), b.ActionConfig); err != nil {
+			return nil, err
+		}
+	}
+	if d := cfg.Duplicates; d != nil {
+		window, err := time.ParseDuration(d.Window)
+		if err != nil || window <= 0 || d.Max < 1 {
+			return nil, fmt.Errorf("filter: rule \"duplicates\": invalid max or window")
+		}
+		if err := add("duplicates", NewDuplicates(d.Max, window), d.ActionConfig); err != nil {
+			return nil, err
+		}
+	}
+	return NewPipeline(steps...), nil
+}
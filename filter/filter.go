@@ -0,0 +1,95 @@
+package filter
+
+import "time"
+
+// Actions a rule may take when it matches, from the strongest to the
+// weakest.
+const (
+	// ActionReject refuses the message.
+	ActionReject = "reject"
+	// ActionHold stores the message hidden until a moderator reviews it.
+	ActionHold = "hold"
+	// ActionLabel stores the message with a label readers can see.
+	ActionLabel = "label"
+)
+
+var strength = map[string]int{ActionLabel: 1, ActionHold: 2, ActionReject: 3}
+
+// Input is a message about to be stored.
+type Input struct {
+	AuthorID int
+	// TweetID is the tweet the message belongs to when editing, or when
+	// recording a stored message. It is 0 for a new tweet being checked.
+	TweetID int
+	Text    string
+	At      time.Time
+}
+
+// Rule decides whether a message breaks it.
+type Rule interface {
+	Match(in Input) bool
+}
+
+// Recorder is implemented by rules that keep state about the messages
+// stored, such as the ones seen recently. Record is called once the message
+// was stored, so refused messages and failed writes are not remembered.
+type Recorder interface {
+	Record(in Input)
+}
+
+// Step is a rule with the action taken when it matches.
+type Step struct {
+	Name   string
+	Rule   Rule
+	Action string
+	// Label is attached to the message when Action is ActionLabel.
+	Label string
+}
+
+// Decision is the outcome of running a message through the pipeline.
+type Decision struct {
+	// Action is the strongest action of the matching rules, or "" when
+	// none matched.
+	Action string
+	// Rules are the names of the matching rules.
+	Rules []string
+	// Labels are the labels of the matching rules whose action is
+	// ActionLabel.
+	Labels []string
+}
+
+// Pipeline runs every step on each message.
+type Pipeline struct {
+	steps []Step
+}
+
+func NewPipeline(steps ...Step) *Pipeline {
+	return &Pipeline{steps: steps}
+}
+
+// Check runs every step on in and combines their actions.
+func (p *Pipeline) Check(in Input) Decision {
+	var d Decision
+	for _, s := range p.steps {
+		if !s.Rule.Match(in) {
+			continue
+		}
+		d.Rules = append(d.Rules, s.Name)
+		if s.Action == ActionLabel {
+			d.Labels = append(d.Labels, s.Label)
+		}
+		if strength[s.Action] > strength[d.Action] {
+			d.Action = s.Action
+		}
+	}
+	return d
+}
+
+// Record tells the stateful rules that in was stored.
+func (p *Pipeline) Record(in Input) {
+	for _, s := range p.steps {
+		if r, ok := s.Rule.(Recorder); ok {
+			r.Record(in)
+		}
+	}
+}
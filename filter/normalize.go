@@ -0,0 +1,117 @@
+package filter
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+
+	"ualabackend/search"
+)
+
+// lookalikes maps characters commonly used to disguise letters to the
+// letter they imitate: digits and symbols, and Cyrillic and Greek letters
+// that look like Latin ones.
+var lookalikes = map[rune]rune{
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '8': 'b',
+	'@': 'a', '$': 's', '!': 'i', '|': 'i', '€': 'e',
+	'а': 'a', 'в': 'b', 'е': 'e', 'к': 'k', 'м': 'm', 'н': 'h', 'о': 'o',
+	'р': 'p', 'с': 'c', 'ѕ': 's', 'т': 't', 'у': 'y', 'х': 'x', 'і': 'i', 'ј': 'j',
+	'α': 'a', 'β': 'b', 'ε': 'e', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o',
+	'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x',
+}
+
+const wordPunctuation = `.,;:!?¡¿"'()[]{}«»…-*`
+
+// minRepeat is the shortest run of a repeated letter that is collapsed:
+// shorter runs are how words are spelled ("polla", "carro"), longer ones are
+// a way of stretching them ("spaaam").
+const minRepeat = 3
+
+// Words returns the words of text in a canonical form meant to defeat
+// obfuscation: compatibility characters such as fullwidth letters are
+// folded, case and diacritics are dropped, invisible characters are
+// removed, lookalikes are replaced by the letter they imitate, runs of
+// single letters ("s p a m") are joined and runs of three or more repeated
+// letters are collapsed.
+func Words(text string) []string {
+	return words(text, false)
+}
+
+// words is Words, keeping digits as written instead of reading them as the
+// letters they resemble when keepDigits is set.
+func words(text string, keepDigits bool) []string {
+	text = norm.NFKC.String(text)
+	text = strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.Cf, r) {
+			return -1
+		}
+		return r
+	}, text)
+	text = search.Normalize(text)
+
+	var words []string
+	single := ""
+	for _, field := range strings.Fields(text) {
+		// Punctuation around a word is not an attempt to disguise it.
+		word := letters(strings.Trim(field, wordPunctuation), keepDigits)
+		if len([]rune(word)) == 1 {
+			single += word
+			continue
+		}
+		// Repeats are collapsed once the run is joined, so the letters of
+		// "p o l l a" are read as "polla" and not as "pola".
+		if single != "" {
+			words = append(words, collapse(single))
+			single = ""
+		}
+		if word != "" {
+			words = append(words, collapse(word))
+		}
+	}
+	if single != "" {
+		words = append(words, collapse(single))
+	}
+	return words
+}
+
+// letters replaces lookalikes in a single word and keeps only its letters.
+// With keepDigits, digits are kept as they are.
+func letters(word string, keepDigits bool) string {
+	var b strings.Builder
+	for _, r := range word {
+		if keepDigits && unicode.IsDigit(r) {
+			b.WriteRune(r)
+			continue
+		}
+		if l, ok := lookalikes[r]; ok {
+			r = l
+		}
+		if unicode.IsLetter(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// collapse replaces every run of at least minRepeat equal letters in word
+// with a single one. Digits are left as written.
+func collapse(word string) string {
+	runes := []rune(word)
+	var b strings.Builder
+	for i := 0; i < len(runes); {
+		j := i
+		for j < len(runes) && runes[j] == runes[i] {
+			j++
+		}
+		n := j - i
+		if n >= minRepeat && unicode.IsLetter(runes[i]) {
+			n = 1
+		}
+		for k := 0; k < n; k++ {
+			b.WriteRune(runes[i])
+		}
+		i = j
+	}
+	return b.String()
+}
@@ -0,0 +1,79 @@
+package filter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "case, diacritics and punctuation",
+			text: "¡Canción, NUEVA!",
+			want: []string{"cancion", "nueva"},
+		},
+		{
+			name: "fullwidth letters are folded",
+			text: "ｓｐａｍ",
+			want: []string{"spam"},
+		},
+		{
+			name: "invisible characters are removed",
+			text: "sp​am",
+			want: []string{"spam"},
+		},
+		{
+			name: "digits and symbols read as letters",
+			text: "Sp4m fr€3 $3ll",
+			want: []string{"spam", "free", "sell"},
+		},
+		{
+			name: "cyrillic and greek lookalikes",
+			text: "ѕрам ρορ",
+			want: []string{"spam", "pop"},
+		},
+		{
+			name: "runs of single letters are joined",
+			text: "compra s p a m ya",
+			want: []string{"compra", "spam", "ya"},
+		},
+		{
+			name: "long repeats are collapsed",
+			text: "spaaaam holaaa",
+			want: []string{"spam", "hola"},
+		},
+		{
+			name: "double letters are kept",
+			text: "polla carro",
+			want: []string{"polla", "carro"},
+		},
+		{
+			name: "double letters in a joined run are kept",
+			text: "p o l l a",
+			want: []string{"polla"},
+		},
+		{
+			name: "long repeats in a joined run are collapsed",
+			text: "s p a a a m",
+			want: []string{"spam"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Words(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Words(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWordsKeepingDigits(t *testing.T) {
+	got := words("Pedido 1111 x3", true)
+	if want := []string{"pedido", "1111", "x3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("words = %q, want %q", got, want)
+	}
+}
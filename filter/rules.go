@@ -0,0 +1,162 @@
+package filter
+
+import (
+	"hash/fnv"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"ualabackend/links"
+)
+
+// WordList matches messages containing any of its words or phrases. Both
+// the list and the messages are compared in the form returned by Words, so
+// "Sp4m", "s p a m" and "ѕрам" all match "spam".
+type WordList struct {
+	phrases []string
+}
+
+func NewWordList(words []string) *WordList {
+	l := &WordList{}
+	for _, w := range words {
+		if phrase := strings.Join(Words(w), " "); phrase != "" {
+			l.phrases = append(l.phrases, " "+phrase+" ")
+		}
+	}
+	return l
+}
+
+func (l *WordList) Match(in Input) bool {
+	text := " " + strings.Join(Words(in.Text), " ") + " "
+	for _, phrase := range l.phrases {
+		if strings.Contains(text, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// LinkBlocklist matches messages linking to any of its domains or their
+// subdomains.
+type LinkBlocklist struct {
+	domains map[string]bool
+}
+
+func NewLinkBlocklist(domains []string) *LinkBlocklist {
+	b := &LinkBlocklist{domains: map[string]bool{}}
+	for _, d := range domains {
+		b.domains[strings.TrimPrefix(strings.ToLower(strings.TrimSpace(d)), "www.")] = true
+	}
+	return b
+}
+
+func (b *LinkBlocklist) Match(in Input) bool {
+	for _, m := range links.Detect(in.Text) {
+		u, err := url.Parse(m.URL)
+		if err != nil {
+			continue
+		}
+		host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+		for host != "" {
+			if b.domains[host] {
+				return true
+			}
+			i := strings.IndexByte(host, '.')
+			if i < 0 {
+				break
+			}
+			host = host[i+1:]
+		}
+	}
+	return false
+}
+
+// Duplicates matches when an author stores the same message more than Max
+// times within Window. Messages are compared in the form returned by Words,
+// except that numbers are kept as written, so small variations in spelling
+// or punctuation count as the same text but "order 1234" and "order 5678"
+// do not. Only recorded messages count, and an edited tweet replaces its
+// previous text instead of counting twice.
+type Duplicates struct {
+	max    int
+	window time.Duration
+
+	mu      sync.Mutex
+	recent  map[int][]sighting
+	records int
+}
+
+type sighting struct {
+	tweetID int
+	hash    uint64
+	at      time.Time
+}
+
+// sweepEvery is how many records happen between sweeps of the authors that
+// stopped posting.
+const sweepEvery = 1000
+
+func NewDuplicates(max int, window time.Duration) *Duplicates {
+	return &Duplicates{max: max, window: window, recent: map[int][]sighting{}}
+}
+
+func (d *Duplicates) Match(in Input) bool {
+	hash, ok := duplicateHash(in.Text)
+	if !ok {
+		return false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	cutoff := in.At.Add(-d.window)
+	count := 1
+	for _, s := range d.recent[in.AuthorID] {
+		if s.at.After(cutoff) && s.hash == hash && (in.TweetID == 0 || s.tweetID != in.TweetID) {
+			count++
+		}
+	}
+	return count > d.max
+}
+
+func (d *Duplicates) Record(in Input) {
+	hash, ok := duplicateHash(in.Text)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	cutoff := in.At.Add(-d.window)
+	d.records++
+	if d.records%sweepEvery == 0 {
+		for author, seen := range d.recent {
+			if len(seen) == 0 || !seen[len(seen)-1].at.After(cutoff) {
+				delete(d.recent, author)
+			}
+		}
+	}
+
+	seen := d.recent[in.AuthorID]
+	kept := seen[:0]
+	for _, s := range seen {
+		if s.at.After(cutoff) && (in.TweetID == 0 || s.tweetID != in.TweetID) {
+			kept = append(kept, s)
+		}
+	}
+	if ok {
+		kept = append(kept, sighting{tweetID: in.TweetID, hash: hash, at: in.At})
+	}
+	d.recent[in.AuthorID] = kept
+}
+
+// duplicateHash returns the hash two messages share when they count as the
+// same text, and false for messages without words.
+func duplicateHash(text string) (uint64, bool) {
+	words := words(text, true)
+	if len(words) == 0 {
+		return 0, false
+	}
+	h := fnv.New64a()
+	h.Write([]byte(strings.Join(words, " ")))
+	return h.Sum64(), true
+}
@@ -0,0 +1,104 @@
+package filter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWordListMatch(t *testing.T) {
+	list := NewWordList([]string{"spam", "pola", "compra ya"})
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"esto es spam", true},
+		{"esto es Sp4m", true},
+		{"esto es ｓｐａｍ", true},
+		{"esto es ѕрам", true},
+		{"esto es s p a m", true},
+		{"esto es spaaaam", true},
+		{"¡Compra, YA!", true},
+		{"la pola de siero", true},
+		{"una polla", false},
+		{"p o l l a", false},
+		{"spammer", false},
+		{"compra mañana", false},
+	}
+	for _, tt := range tests {
+		if got := list.Match(Input{Text: tt.text}); got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	start := time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		prior []Input
+		in    Input
+		want  bool
+	}{
+		{
+			name:  "under the limit",
+			prior: []Input{{AuthorID: 1, TweetID: 1, Text: "hola"}},
+			in:    Input{AuthorID: 1, Text: "hola"},
+		},
+		{
+			name: "same text with spelling variations",
+			prior: []Input{
+				{AuthorID: 1, TweetID: 1, Text: "Hola!"},
+				{AuthorID: 1, TweetID: 2, Text: "h o l a"},
+			},
+			in:   Input{AuthorID: 1, Text: "HOLAAA"},
+			want: true,
+		},
+		{
+			name: "different numbers",
+			prior: []Input{
+				{AuthorID: 1, TweetID: 1, Text: "pedido 1234"},
+				{AuthorID: 1, TweetID: 2, Text: "pedido 5678"},
+			},
+			in: Input{AuthorID: 1, Text: "pedido 9012"},
+		},
+		{
+			name: "other authors",
+			prior: []Input{
+				{AuthorID: 2, TweetID: 1, Text: "hola"},
+				{AuthorID: 3, TweetID: 2, Text: "hola"},
+			},
+			in: Input{AuthorID: 1, Text: "hola"},
+		},
+		{
+			name: "outside the window",
+			prior: []Input{
+				{AuthorID: 1, TweetID: 1, Text: "hola", At: start.Add(-2 * time.Hour)},
+				{AuthorID: 1, TweetID: 2, Text: "hola"},
+			},
+			in: Input{AuthorID: 1, Text: "hola"},
+		},
+		{
+			name: "editing a tweet does not count it twice",
+			prior: []Input{
+				{AuthorID: 1, TweetID: 1, Text: "hola"},
+				{AuthorID: 1, TweetID: 2, Text: "hola"},
+			},
+			in: Input{AuthorID: 1, TweetID: 2, Text: "hola"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDuplicates(2, time.Hour)
+			for _, in := range tt.prior {
+				if in.At.IsZero() {
+					in.At = start
+				}
+				d.Record(in)
+			}
+			tt.in.At = start
+			if got := d.Match(tt.in); got != tt.want {
+				t.Errorf("Match(%q) = %v, want %v", tt.in.Text, got, tt.want)
+			}
+		})
+	}
+}
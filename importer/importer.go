@@ -0,0 +1,452 @@
+package importer
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	userRepo "ualabackend/repositories/user"
+)
+
+const (
+	FormatNDJSON = "ndjson"
+	FormatCSV    = "csv"
+
+	TypeUser   = "user"
+	TypeFollow = "follow"
+	TypeTweet  = "tweet"
+
+	// DefaultBatchSize is how many rows are committed per transaction.
+	DefaultBatchSize = 500
+
+	maxLineSize = 1 << 20
+)
+
+// Record is one row of an import file. Users are identified by ExternalID
+// and referenced by it from follows (Follower, Followed) and tweets (Author).
+// A row may only reference users created by earlier rows or earlier imports.
+type Record struct {
+	Type       string     `json:"type" example:"user"`
+	ExternalID string     `json:"external_id,omitempty" example:"u-1"`
+	Name       string     `json:"name,omitempty" example:"John Doe"`
+	Follower   string     `json:"follower,omitempty" example:"u-1"`
+	Followed   string     `json:"followed,omitempty" example:"u-2"`
+	Author     string     `json:"author,omitempty" example:"u-1"`
+	Message    string     `json:"message,omitempty" example:"Hola mundo"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+}
+
+// RowError reports why a row was skipped. Line is 1-based and counts the CSV
+// header.
+type RowError struct {
+	Line       int    `json:"line" example:"3"`
+	ExternalID string `json:"external_id,omitempty" example:"u-1"`
+	Error      string `json:"error" example:"unknown user \"u-9\""`
+}
+
+// Report summarizes an import.
+type Report struct {
+	Users   int        `json:"users" example:"10"`
+	Follows int        `json:"follows" example:"25"`
+	Tweets  int        `json:"tweets" example:"100"`
+	Errors  []RowError `json:"errors"`
+	// Imported lists the rows inserted by committed batches, in order, for
+	// the caller to audit.
+	Imported []Imported `json:"-"`
+}
+
+// Imported is a row an import inserted. ID is the user or tweet created, or
+// the followed user of a follow. FollowerID is only set for follows and
+// AuthorID only for tweets.
+type Imported struct {
+	Type       string
+	ID         int
+	FollowerID int
+	AuthorID   int
+	Record     Record
+}
+
+// Importer loads users, follows and tweets in bulk. Rows are inserted in
+// batches, one transaction per batch, with a savepoint per row so a bad row
+// only discards itself. Derived data (counters, follow arrays and feeds) is
+// rebuilt once at the end instead of per row.
+type Importer struct {
+	DB        *sql.DB
+	Users     *userRepo.Repository
+	BatchSize int
+
+	users map[string]int
+}
+
+func New(db *sql.DB, users *userRepo.Repository) *Importer {
+	return &Importer{DB: db, Users: users, BatchSize: DefaultBatchSize}
+}
+
+type row struct {
+	line   int
+	record Record
+	err    error
+}
+
+// Run imports every row read from r in the given format.
+func (im *Importer) Run(r io.Reader, format string) (*Report, error) {
+	var next func() (row, error)
+	switch format {
+	case FormatNDJSON:
+		next = ndjsonRows(r)
+	case FormatCSV:
+		var err error
+		if next, err = csvRows(r); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+
+	im.users = map[string]int{}
+	report := &Report{Errors: []RowError{}}
+	batch := make([]row, 0, im.BatchSize)
+	for {
+		rw, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return report, err
+		}
+		batch = append(batch, rw)
+		if len(batch) == im.BatchSize {
+			if err := im.commit(batch, report); err != nil {
+				return report, err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := im.commit(batch, report); err != nil {
+		return report, err
+	}
+
+	if _, err := im.Users.RecomputeCounters(); err != nil {
+		return report, err
+	}
+	if _, err := im.Users.RebuildTimelines(); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// commit inserts a batch in a single transaction.
+func (im *Importer) commit(batch []row, report *Report) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	tx, err := im.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	created := map[string]int{}
+	var counts Report
+	var failures []RowError
+	for _, rw := range batch {
+		err := rw.err
+		if err == nil {
+			err = im.insert(tx, rw.record, created, &counts)
+		}
+		if err != nil {
+			failures = append(failures, RowError{Line: rw.line, ExternalID: rw.record.ExternalID, Error: err.Error()})
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for ext, id := range created {
+		im.users[ext] = id
+	}
+	report.Users += counts.Users
+	report.Follows += counts.Follows
+	report.Tweets += counts.Tweets
+	report.Errors = append(report.Errors, failures...)
+	report.Imported = append(report.Imported, counts.Imported...)
+	return nil
+}
+
+// insert applies one record inside a savepoint, rolling back to it on error.
+func (im *Importer) insert(tx *sql.Tx, rec Record, created map[string]int, counts *Report) error {
+	if err := validate(rec); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`SAVEPOINT import_row`); err != nil {
+		return err
+	}
+
+	var imported Imported
+	var err error
+	switch rec.Type {
+	case TypeUser:
+		imported, err = im.insertUser(tx, rec, created)
+		if err == nil {
+			counts.Users++
+			counts.Imported = append(counts.Imported, imported)
+		}
+	case TypeFollow:
+		var inserted bool
+		imported, inserted, err = im.insertFollow(tx, rec, created)
+		if err == nil && inserted {
+			counts.Follows++
+			counts.Imported = append(counts.Imported, imported)
+		}
+	case TypeTweet:
+		imported, err = im.insertTweet(tx, rec, created)
+		if err == nil {
+			counts.Tweets++
+			counts.Imported = append(counts.Imported, imported)
+		}
+	}
+
+	if err != nil {
+		if _, rbErr := tx.Exec(`ROLLBACK TO SAVEPOINT import_row`); rbErr != nil {
+			return rbErr
+		}
+		return err
+	}
+	return nil
+}
+
+func validate(rec Record) error {
+	switch rec.Type {
+	case TypeUser:
+		if rec.ExternalID == "" || strings.TrimSpace(rec.Name) == "" {
+			return errors.New("users require external_id and name")
+		}
+	case TypeFollow:
+		if rec.Follower == "" || rec.Followed == "" {
+			return errors.New("follows require follower and followed")
+		}
+		if rec.Follower == rec.Followed {
+			return errors.New("a user cannot follow itself")
+		}
+	case TypeTweet:
+		if rec.Author == "" || strings.TrimSpace(rec.Message) == "" {
+			return errors.New("tweets require author and message")
+		}
+	default:
+		return fmt.Errorf("unknown type %q", rec.Type)
+	}
+	return nil
+}
+
+func (im *Importer) insertUser(tx *sql.Tx, rec Record, created map[string]int) (Imported, error) {
+	var exists bool
+	err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM imported_users WHERE external_id = ?)`, rec.ExternalID).Scan(&exists)
+	if err != nil {
+		return Imported{}, err
+	}
+	if _, ok := created[rec.ExternalID]; ok || exists {
+		return Imported{}, fmt.Errorf("user %q was already imported", rec.ExternalID)
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO users (name, followers_id, following_id, feed)
+		VALUES (?, '[]', '[]', '[]')
+	`, rec.Name)
+	if err != nil {
+		return Imported{}, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return Imported{}, err
+	}
+
+	_, err = tx.Exec(`INSERT INTO imported_users (external_id, user_id) VALUES (?, ?)`, rec.ExternalID, id)
+	if err != nil {
+		return Imported{}, err
+	}
+	created[rec.ExternalID] = int(id)
+	return Imported{Type: TypeUser, ID: int(id), Record: rec}, nil
+}
+
+func (im *Importer) insertFollow(tx *sql.Tx, rec Record, created map[string]int) (Imported, bool, error) {
+	followerID, err := im.resolve(tx, rec.Follower, created)
+	if err != nil {
+		return Imported{}, false, err
+	}
+	followedID, err := im.resolve(tx, rec.Followed, created)
+	if err != nil {
+		return Imported{}, false, err
+	}
+
+	result, err := tx.Exec(`
+		INSERT IGNORE INTO follows (follower_id, followed_id, created_at) VALUES (?, ?, ?)
+	`, followerID, followedID, timestamp(rec))
+	if err != nil {
+		return Imported{}, false, err
+	}
+	affected, err := result.RowsAffected()
+	imported := Imported{Type: TypeFollow, ID: followedID, FollowerID: followerID, Record: rec}
+	return imported, affected > 0, err
+}
+
+func (im *Importer) insertTweet(tx *sql.Tx, rec Record, created map[string]int) (Imported, error) {
+	authorID, err := im.resolve(tx, rec.Author, created)
+	if err != nil {
+		return Imported{}, err
+	}
+	if rec.ExternalID != "" {
+		var exists bool
+		err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM imported_tweets WHERE external_id = ?)`, rec.ExternalID).Scan(&exists)
+		if err != nil {
+			return Imported{}, err
+		}
+		if exists {
+			return Imported{}, fmt.Errorf("tweet %q was already imported", rec.ExternalID)
+		}
+	}
+
+	at := timestamp(rec)
+	result, err := tx.Exec(`INSERT INTO tweets (author_id, message, timestamp) VALUES (?, ?, ?)`, authorID, rec.Message, at)
+	if err != nil {
+		return Imported{}, err
+	}
+	tweetID, err := result.LastInsertId()
+	if err != nil {
+		return Imported{}, err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO tweet_revisions (tweet_id, revision, message, created_at) VALUES (?, 1, ?, ?)
+	`, tweetID, rec.Message, at)
+	if err != nil {
+		return Imported{}, err
+	}
+
+	if rec.ExternalID != "" {
+		_, err = tx.Exec(`INSERT INTO imported_tweets (external_id, tweet_id) VALUES (?, ?)`, rec.ExternalID, tweetID)
+	}
+	return Imported{Type: TypeTweet, ID: int(tweetID), AuthorID: authorID, Record: rec}, err
+}
+
+// resolve maps a user's external ID to its internal ID, looking at the rows
+// of the current batch first, then earlier batches and finally earlier
+// imports.
+func (im *Importer) resolve(tx *sql.Tx, externalID string, created map[string]int) (int, error) {
+	if id, ok := created[externalID]; ok {
+		return id, nil
+	}
+	if id, ok := im.users[externalID]; ok {
+		return id, nil
+	}
+
+	var id int
+	err := tx.QueryRow(`SELECT user_id FROM imported_users WHERE external_id = ?`, externalID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("unknown user %q", externalID)
+	}
+	if err != nil {
+		return 0, err
+	}
+	im.users[externalID] = id
+	return id, nil
+}
+
+func timestamp(rec Record) time.Time {
+	if rec.CreatedAt != nil {
+		return *rec.CreatedAt
+	}
+	return time.Now()
+}
+
+// ndjsonRows reads one JSON record per line. Blank lines are ignored and
+// malformed lines are reported as row errors.
+func ndjsonRows(r io.Reader) func() (row, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	line := 0
+
+	return func() (row, error) {
+		for scanner.Scan() {
+			line++
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" {
+				continue
+			}
+			var rec Record
+			err := json.Unmarshal([]byte(text), &rec)
+			return row{line: line, record: rec, err: err}, nil
+		}
+		if err := scanner.Err(); err != nil {
+			return row{}, err
+		}
+		return row{}, io.EOF
+	}
+}
+
+// csvRows reads records from a CSV file whose header names the Record
+// fields by their JSON names. Unknown columns are ignored.
+func csvRows(r io.Reader) (func() (row, error), error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return func() (row, error) { return row{}, io.EOF }, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	if _, ok := columns["type"]; !ok {
+		return nil, errors.New("CSV header must include a type column")
+	}
+
+	line := 1
+	return func() (row, error) {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			return row{}, io.EOF
+		}
+		line++
+		if err != nil {
+			if _, ok := err.(*csv.ParseError); ok {
+				return row{line: line, err: err}, nil
+			}
+			return row{}, err
+		}
+
+		get := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(fields) {
+				return strings.TrimSpace(fields[i])
+			}
+			return ""
+		}
+		rec := Record{
+			Type:       get("type"),
+			ExternalID: get("external_id"),
+			Name:       get("name"),
+			Follower:   get("follower"),
+			Followed:   get("followed"),
+			Author:     get("author"),
+			Message:    get("message"),
+		}
+		if v := get("created_at"); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return row{line: line, record: rec, err: fmt.Errorf("invalid created_at %q", v)}, nil
+			}
+			rec.CreatedAt = &t
+		}
+		return row{line: line, record: rec}, nil
+	}, nil
+}
@@ -0,0 +1,20 @@
+package jobs
+
+import (
+	"log"
+	"time"
+)
+
+// Every runs fn immediately and then once per interval, logging failures
+// instead of stopping. It blocks, so it is meant to be started with go.
+func Every(interval time.Duration, name string, fn func() error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := fn(); err != nil {
+			log.Printf("⚠️ Job %s failed: %v", name, err)
+		}
+		<-ticker.C
+	}
+}
@@ -0,0 +1,71 @@
+package links
+
+import (
+	"crypto/rand"
+	"math/big"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// CodeLength is the length of generated short codes.
+	CodeLength = 7
+
+	// maxDisplayLength is how many characters of a URL are shown before
+	// truncating it with an ellipsis.
+	maxDisplayLength = 25
+
+	codeAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+)
+
+var urlPattern = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+// Match is a URL found in a text. Start and End are byte offsets.
+type Match struct {
+	Start, End int
+	URL        string
+}
+
+// Detect returns the http(s) URLs in text, in order. Trailing punctuation
+// that usually closes a sentence is not considered part of the URL.
+func Detect(text string) []Match {
+	var matches []Match
+	for _, loc := range urlPattern.FindAllStringIndex(text, -1) {
+		end := loc[1]
+		for end > loc[0] && strings.ContainsRune(".,;:!?)]}'", rune(text[end-1])) {
+			end--
+		}
+		url := text[loc[0]:end]
+		if url == "http://" || url == "https://" {
+			continue
+		}
+		matches = append(matches, Match{Start: loc[0], End: end, URL: url})
+	}
+	return matches
+}
+
+// Display returns the text shown in place of url: without the scheme and
+// truncated when too long.
+func Display(url string) string {
+	display := strings.TrimPrefix(strings.TrimPrefix(url, "https://"), "http://")
+	display = strings.TrimPrefix(display, "www.")
+	if utf8.RuneCountInString(display) > maxDisplayLength {
+		display = string([]rune(display)[:maxDisplayLength]) + "…"
+	}
+	return display
+}
+
+// NewCode returns a random short code.
+func NewCode() (string, error) {
+	code := make([]byte, CodeLength)
+	max := big.NewInt(int64(len(codeAlphabet)))
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = codeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	"os"
 
 	"ualabackend/api"
 	"ualabackend/db"
@@ -10,10 +11,17 @@ import (
 )
 
 func main() {
-	_, err := db.InitDB()
+	database, err := db.InitDB()
 	if err != nil {
 		log.Fatalf("Could not connect to DB: %v", err)
 	}
 
+	if len(os.Args) > 1 {
+		if err := runCommand(database, os.Args[1], os.Args[2:]); err != nil {
+			log.Fatalf("%s: %v", os.Args[1], err)
+		}
+		return
+	}
+
 	api.InitAPI()
 }
@@ -0,0 +1,136 @@
+package mediafile
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"net/http"
+
+	_ "image/gif"
+	_ "image/png"
+)
+
+const (
+	KindImage = "image"
+	KindVideo = "video"
+
+	MaxImageSize = 5 << 20
+	MaxVideoSize = 50 << 20
+
+	// ThumbnailSize is the longest side of generated thumbnails, in pixels.
+	ThumbnailSize = 320
+
+	// MaxImagePixels bounds the decoded size of an image. A few kilobytes of
+	// compressed data can declare huge dimensions, so they are checked before
+	// the image is decoded.
+	MaxImagePixels = 40_000_000
+
+	// sniffLength is how many bytes http.DetectContentType looks at.
+	sniffLength = 512
+)
+
+// ErrUnsupportedType is returned for content that is not an accepted image or
+// video format.
+var ErrUnsupportedType = errors.New("unsupported media type")
+
+// ErrTooManyPixels is returned for images whose dimensions exceed
+// MaxImagePixels.
+var ErrTooManyPixels = errors.New("image dimensions too large")
+
+// allowed maps the sniffed MIME types we accept to their kind.
+var allowed = map[string]string{
+	"image/jpeg": KindImage,
+	"image/png":  KindImage,
+	"image/gif":  KindImage,
+	"video/mp4":  KindVideo,
+	"video/webm": KindVideo,
+}
+
+// Sniff detects the MIME type from the content itself, ignoring whatever the
+// client declared, and returns it with its kind.
+func Sniff(data []byte) (mimeType, kind string, err error) {
+	if len(data) > sniffLength {
+		data = data[:sniffLength]
+	}
+	mimeType = http.DetectContentType(data)
+	kind, ok := allowed[mimeType]
+	if !ok {
+		return mimeType, "", ErrUnsupportedType
+	}
+	return mimeType, kind, nil
+}
+
+// MaxSize returns the upload limit for a kind.
+func MaxSize(kind string) int64 {
+	if kind == KindVideo {
+		return MaxVideoSize
+	}
+	return MaxImageSize
+}
+
+// Thumbnail decodes an image and returns its dimensions and a JPEG scaled
+// down so that its longest side is at most ThumbnailSize. Images larger than
+// MaxImagePixels are rejected without being decoded.
+func Thumbnail(data []byte) (width, height int, thumb []byte, err error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || int64(cfg.Width)*int64(cfg.Height) > MaxImagePixels {
+		return 0, 0, nil, ErrTooManyPixels
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	b := src.Bounds()
+	width, height = b.Dx(), b.Dy()
+
+	tw, th := width, height
+	if tw > ThumbnailSize || th > ThumbnailSize {
+		if tw >= th {
+			tw, th = ThumbnailSize, max(1, height*ThumbnailSize/width)
+		} else {
+			tw, th = max(1, width*ThumbnailSize/height), ThumbnailSize
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, scale(src, tw, th), &jpeg.Options{Quality: 80}); err != nil {
+		return 0, 0, nil, err
+	}
+	return width, height, buf.Bytes(), nil
+}
+
+// scale resizes src to w×h by averaging the source pixels that fall in each
+// destination pixel (a box filter), which is enough for downscaling.
+func scale(src image.Image, w, h int) image.Image {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0 := b.Min.Y + y*b.Dy()/h
+		y1 := max(y0+1, b.Min.Y+(y+1)*b.Dy()/h)
+		for x := 0; x < w; x++ {
+			x0 := b.Min.X + x*b.Dx()/w
+			x1 := max(x0+1, b.Min.X+(x+1)*b.Dx()/w)
+
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			// JPEG has no alpha: composite transparent pixels over white.
+			bg := 0xffff - a/n
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r/n + bg), G: uint16(g/n + bg), B: uint16(bl/n + bg), A: 0xffff,
+			})
+		}
+	}
+	return dst
+}
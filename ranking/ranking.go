@@ -0,0 +1,170 @@
+package ranking
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"ualabackend/entities/tweet"
+)
+
+const (
+	// DegreeFollowed marks a candidate written by an account the viewer follows.
+	DegreeFollowed = 1
+	// DegreeNetwork marks a candidate written by an account followed by
+	// someone the viewer follows.
+	DegreeNetwork = 2
+)
+
+// Candidate is a tweet that may appear in a ranked timeline along with the
+// signals it is scored on.
+type Candidate struct {
+	Tweet  tweet.Tweet
+	Degree int
+	// Engagement counts the interactions the tweet received from anyone.
+	Engagement int
+	// Affinity counts the viewer's past interactions with the author.
+	Affinity int
+}
+
+// Scorer rates a candidate as seen at now. Higher is better.
+type Scorer interface {
+	Score(c Candidate, now time.Time) float64
+}
+
+// ScorerFunc adapts a function to a Scorer.
+type ScorerFunc func(c Candidate, now time.Time) float64
+
+func (f ScorerFunc) Score(c Candidate, now time.Time) float64 { return f(c, now) }
+
+// Recency halves a tweet's score every HalfLife.
+type Recency struct {
+	HalfLife time.Duration
+}
+
+func (s Recency) Score(c Candidate, now time.Time) float64 {
+	age := now.Sub(c.Tweet.Timestamp)
+	if age < 0 {
+		age = 0
+	}
+	return math.Exp2(-float64(age) / float64(s.HalfLife))
+}
+
+// Engagement grows with the logarithm of the interactions a tweet received,
+// so a few viral tweets do not take over the timeline.
+type Engagement struct{}
+
+func (Engagement) Score(c Candidate, _ time.Time) float64 {
+	return math.Log1p(float64(c.Engagement))
+}
+
+// Affinity favors authors the viewer interacts with and penalizes the ones
+// reached only through the network.
+type Affinity struct {
+	// NetworkPenalty multiplies the score of second-degree candidates.
+	NetworkPenalty float64
+}
+
+func (s Affinity) Score(c Candidate, _ time.Time) float64 {
+	score := 1 + math.Log1p(float64(c.Affinity))
+	if c.Degree == DegreeNetwork {
+		score *= s.NetworkPenalty
+	}
+	return score
+}
+
+// Factor is a scorer and the weight of its score in the final ranking.
+type Factor struct {
+	Scorer Scorer
+	Weight float64
+}
+
+// DefaultFactors weigh recency highest, then affinity, then engagement.
+var DefaultFactors = []Factor{
+	{Scorer: Recency{HalfLife: 6 * time.Hour}, Weight: 3},
+	{Scorer: Affinity{NetworkPenalty: 0.5}, Weight: 2},
+	{Scorer: Engagement{}, Weight: 1},
+}
+
+// Source provides the candidates of a user's ranked timeline: the tweets
+// posted between since and until that the user may read.
+type Source interface {
+	RankingCandidates(userID int, since, until time.Time, limit int) ([]Candidate, error)
+}
+
+// Ranker builds ranked timelines: it scores every candidate with the
+// weighted sum of its factors and orders them best first. Ties are broken by
+// tweet ID, newest first, so the same input always yields the same order.
+type Ranker struct {
+	source  Source
+	factors []Factor
+	now     func() time.Time
+
+	// Horizon is how old a tweet may be to be considered.
+	Horizon time.Duration
+	// PoolSize bounds how many candidates are scored per request.
+	PoolSize int
+}
+
+// NewRanker returns a ranker over source. now is the clock recency is
+// measured against; nil means time.Now.
+func NewRanker(source Source, factors []Factor, now func() time.Time) *Ranker {
+	if now == nil {
+		now = time.Now
+	}
+	return &Ranker{
+		source:   source,
+		factors:  factors,
+		now:      now,
+		Horizon:  7 * 24 * time.Hour,
+		PoolSize: 500,
+	}
+}
+
+// Timeline returns a page of userID's ranked timeline scored as seen at at,
+// which also bounds the candidates so every page of a session ranks the same
+// tweets. A zero at means now. It returns the time the page was scored at,
+// for the client to pass back when asking for the next pages.
+func (r *Ranker) Timeline(userID int, at time.Time, limit, offset int) ([]tweet.Tweet, time.Time, error) {
+	if at.IsZero() {
+		at = r.now()
+	}
+	candidates, err := r.source.RankingCandidates(userID, at.Add(-r.Horizon), at, r.PoolSize)
+	if err != nil {
+		return nil, at, err
+	}
+
+	ranked := r.Rank(candidates, at)
+	tweets := []tweet.Tweet{}
+	for i := offset; i < len(ranked) && len(tweets) < limit; i++ {
+		tweets = append(tweets, ranked[i].Tweet)
+	}
+	return tweets, at, nil
+}
+
+// Rank orders candidates best first as seen at now.
+func (r *Ranker) Rank(candidates []Candidate, now time.Time) []Candidate {
+	type scored struct {
+		Candidate
+		score float64
+	}
+	all := make([]scored, len(candidates))
+	for i, c := range candidates {
+		all[i] = scored{Candidate: c}
+		for _, f := range r.factors {
+			all[i].score += f.Weight * f.Scorer.Score(c, now)
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].score != all[j].score {
+			return all[i].score > all[j].score
+		}
+		return all[i].Tweet.Id > all[j].Tweet.Id
+	})
+
+	ranked := make([]Candidate, len(all))
+	for i, s := range all {
+		ranked[i] = s.Candidate
+	}
+	return ranked
+}
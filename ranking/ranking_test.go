@@ -0,0 +1,191 @@
+package ranking
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"ualabackend/entities/tweet"
+)
+
+var now = time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC)
+
+// candidate returns a followed, unengaged candidate posted age before now.
+func candidate(id int, age time.Duration) Candidate {
+	return Candidate{
+		Tweet:  tweet.Tweet{Id: id, Timestamp: now.Add(-age)},
+		Degree: DegreeFollowed,
+	}
+}
+
+func ids(candidates []Candidate) []int {
+	out := make([]int, len(candidates))
+	for i, c := range candidates {
+		out[i] = c.Tweet.Id
+	}
+	return out
+}
+
+func equalIDs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRecencyHalvesEveryHalfLife(t *testing.T) {
+	s := Recency{HalfLife: 6 * time.Hour}
+	tests := []struct {
+		age  time.Duration
+		want float64
+	}{
+		{0, 1},
+		{6 * time.Hour, 0.5},
+		{12 * time.Hour, 0.25},
+		{-time.Hour, 1}, // clock skew: tweets from the future count as new
+	}
+	for _, tt := range tests {
+		if got := s.Score(candidate(1, tt.age), now); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Score(age %v) = %v, want %v", tt.age, got, tt.want)
+		}
+	}
+}
+
+func TestRankOrdersByRecency(t *testing.T) {
+	r := NewRanker(nil, []Factor{{Scorer: Recency{HalfLife: time.Hour}, Weight: 1}}, nil)
+	got := ids(r.Rank([]Candidate{
+		candidate(1, 3*time.Hour),
+		candidate(2, 0),
+		candidate(3, time.Hour),
+	}, now))
+	if want := []int{2, 3, 1}; !equalIDs(got, want) {
+		t.Errorf("Rank = %v, want %v", got, want)
+	}
+}
+
+func TestAffinityPenalizesNetwork(t *testing.T) {
+	s := Affinity{NetworkPenalty: 0.5}
+	followed := candidate(1, 0)
+	followed.Affinity = 3
+	network := followed
+	network.Degree = DegreeNetwork
+
+	if got, want := s.Score(network, now), s.Score(followed, now)*0.5; math.Abs(got-want) > 1e-9 {
+		t.Errorf("network score = %v, want %v", got, want)
+	}
+
+	// Same age and affinity: the followed author's tweet wins even with a
+	// lower ID.
+	r := NewRanker(nil, DefaultFactors, nil)
+	network.Tweet.Id = 2
+	if got, want := ids(r.Rank([]Candidate{network, followed}, now)), []int{1, 2}; !equalIDs(got, want) {
+		t.Errorf("Rank = %v, want %v", got, want)
+	}
+}
+
+func TestEngagementIsLogScaled(t *testing.T) {
+	s := Engagement{}
+	score := func(n int) float64 {
+		c := candidate(1, 0)
+		c.Engagement = n
+		return s.Score(c, now)
+	}
+
+	if got := score(0); got != 0 {
+		t.Errorf("Score(0) = %v, want 0", got)
+	}
+	// Each tenfold increase adds roughly the same amount, so a viral tweet
+	// is not a thousand times ahead of a merely popular one.
+	small, large := score(99)-score(9), score(9999)-score(999)
+	if math.Abs(small-large) > 0.01 {
+		t.Errorf("tenfold increases add %v and %v, want them equal", small, large)
+	}
+
+	// Under the defaults a viral tweet from a few hours ago still beats a
+	// fresh one nobody interacted with.
+	r := NewRanker(nil, DefaultFactors, nil)
+	fresh := candidate(1, 0)
+	viral := candidate(2, 3*time.Hour)
+	viral.Engagement = 1000
+	if got, want := ids(r.Rank([]Candidate{fresh, viral}, now)), []int{2, 1}; !equalIDs(got, want) {
+		t.Errorf("Rank = %v, want %v", got, want)
+	}
+}
+
+func TestRankBreaksTiesByNewestID(t *testing.T) {
+	r := NewRanker(nil, DefaultFactors, nil)
+	got := ids(r.Rank([]Candidate{
+		candidate(3, time.Hour),
+		candidate(7, time.Hour),
+		candidate(5, time.Hour),
+	}, now))
+	if want := []int{7, 5, 3}; !equalIDs(got, want) {
+		t.Errorf("Rank = %v, want %v", got, want)
+	}
+}
+
+type fakeSource struct {
+	candidates   []Candidate
+	userID       int
+	since, until time.Time
+	limit        int
+}
+
+func (s *fakeSource) RankingCandidates(userID int, since, until time.Time, limit int) ([]Candidate, error) {
+	s.userID, s.since, s.until, s.limit = userID, since, until, limit
+	return s.candidates, nil
+}
+
+func TestTimelinePagesRankedCandidates(t *testing.T) {
+	source := &fakeSource{candidates: []Candidate{
+		candidate(1, 4*time.Hour),
+		candidate(2, 3*time.Hour),
+		candidate(3, 2*time.Hour),
+		candidate(4, time.Hour),
+	}}
+	r := NewRanker(source, DefaultFactors, func() time.Time { return now })
+
+	tweets, at, err := r.Timeline(10, time.Time{}, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]int, len(tweets))
+	for i, tw := range tweets {
+		got[i] = tw.Id
+	}
+	if want := []int{3, 2}; !equalIDs(got, want) {
+		t.Errorf("Timeline = %v, want %v", got, want)
+	}
+	if !at.Equal(now) {
+		t.Errorf("Timeline scored at %v, want %v", at, now)
+	}
+	if source.userID != 10 {
+		t.Errorf("source asked for user %d, want 10", source.userID)
+	}
+	if want := now.Add(-r.Horizon); !source.since.Equal(want) || !source.until.Equal(now) || source.limit != r.PoolSize {
+		t.Errorf("source asked from %v to %v limit %d, want %v, %v and %d",
+			source.since, source.until, source.limit, want, now, r.PoolSize)
+	}
+}
+
+func TestTimelineKeepsThePinnedTime(t *testing.T) {
+	source := &fakeSource{candidates: []Candidate{
+		candidate(1, 4*time.Hour),
+		candidate(2, 3*time.Hour),
+	}}
+	later := now.Add(24 * time.Hour)
+	r := NewRanker(source, DefaultFactors, func() time.Time { return later })
+
+	_, at, err := r.Timeline(10, now, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !at.Equal(now) || !source.until.Equal(now) || !source.since.Equal(now.Add(-r.Horizon)) {
+		t.Errorf("Timeline(at=%v) scored at %v from %v to %v, want the pinned time", now, at, source.since, source.until)
+	}
+}
@@ -0,0 +1,196 @@
+package accountRepo
+
+import (
+	"database/sql"
+)
+
+// step is one stage of the account deletion pipeline. Every table that
+// references users must have a step here.
+type step struct {
+	name  string
+	query string
+	args  int
+}
+
+// deletionSteps run in order inside a single transaction. Each query takes
+// the user ID args times.
+var deletionSteps = []step{
+	// Timelines: drop the user's tweets from every feed.
+	{"feeds", `
+		UPDATE users SET feed = (
+			SELECT COALESCE(JSON_ARRAYAGG(f.tweet_id), JSON_ARRAY())
+			FROM JSON_TABLE(users.feed, '$[*]' COLUMNS (tweet_id BIGINT PATH '$')) f
+			WHERE f.tweet_id NOT IN (SELECT id FROM tweets WHERE author_id = ?)
+		)
+		WHERE id <> ?
+	`, 2},
+	// Reports filed by the user or about them or their tweets. The moderation
+	// audit trail is kept.
+	{"reports", `
+		DELETE FROM reports
+		WHERE reporter_id = ?
+			OR (target_type = 'user' AND target_id = ?)
+			OR (target_type = 'tweet' AND target_id IN (SELECT id FROM tweets WHERE author_id = ?))
+	`, 3},
+	// Poll votes are dropped but the tallies they added are kept, anonymized.
+	{"poll votes", `DELETE FROM poll_votes WHERE user_id = ?`, 1},
+	{"tweets", `DELETE FROM tweets WHERE author_id = ?`, 1},
+	{"scheduled tweets", `DELETE FROM scheduled_tweets WHERE author_id = ?`, 1},
+	{"drafts", `DELETE FROM drafts WHERE user_id = ?`, 1},
+	// Media rows are disowned; the cleanup job deletes them with their blobs.
+	{"media", `UPDATE media SET owner_id = NULL WHERE owner_id = ?`, 1},
+
+	// Follows: keep the other side's counters and arrays consistent.
+	{"followers counters", `
+		UPDATE users u JOIN follows f ON f.followed_id = u.id
+		SET u.followers_count = GREATEST(u.followers_count - 1, 0)
+		WHERE f.follower_id = ?
+	`, 1},
+	{"following counters", `
+		UPDATE users u JOIN follows f ON f.follower_id = u.id
+		SET u.following_count = GREATEST(u.following_count - 1, 0)
+		WHERE f.followed_id = ?
+	`, 1},
+	{"followers arrays", `
+		UPDATE users SET followers_id = (
+			SELECT COALESCE(JSON_ARRAYAGG(x.id), JSON_ARRAY())
+			FROM JSON_TABLE(users.followers_id, '$[*]' COLUMNS (id BIGINT PATH '$')) x
+			WHERE x.id <> ?
+		)
+		WHERE JSON_CONTAINS(followers_id, CAST(? AS JSON))
+	`, 2},
+	{"following arrays", `
+		UPDATE users SET following_id = (
+			SELECT COALESCE(JSON_ARRAYAGG(x.id), JSON_ARRAY())
+			FROM JSON_TABLE(users.following_id, '$[*]' COLUMNS (id BIGINT PATH '$')) x
+			WHERE x.id <> ?
+		)
+		WHERE JSON_CONTAINS(following_id, CAST(? AS JSON))
+	`, 2},
+	{"follows", `DELETE FROM follows WHERE follower_id = ? OR followed_id = ?`, 2},
+	{"follow requests", `DELETE FROM follow_requests WHERE requester_id = ? OR target_id = ?`, 2},
+	{"blocks", `DELETE FROM blocks WHERE blocker_id = ? OR blocked_id = ?`, 2},
+	{"mutes", `DELETE FROM mutes WHERE muter_id = ? OR muted_id = ?`, 2},
+
+	// Lists: owned lists cascade to their members and subscribers.
+	{"lists", `DELETE FROM lists WHERE owner_id = ?`, 1},
+	{"list members", `DELETE FROM list_members WHERE user_id = ?`, 1},
+	{"list subscribers", `DELETE FROM list_subscribers WHERE user_id = ?`, 1},
+
+	// Direct messages: the user's messages go away with their participation.
+	{"message deletions", `
+		DELETE d FROM message_deletions d JOIN messages m ON m.id = d.message_id
+		WHERE m.sender_id = ? OR d.user_id = ?
+	`, 2},
+	{"messages", `DELETE FROM messages WHERE sender_id = ?`, 1},
+	{"participants", `DELETE FROM conversation_participants WHERE user_id = ?`, 1},
+	{"orphan message deletions", `
+		DELETE d FROM message_deletions d JOIN messages m ON m.id = d.message_id
+		WHERE NOT EXISTS (SELECT 1 FROM conversation_participants p WHERE p.conversation_id = m.conversation_id)
+	`, 0},
+	{"orphan messages", `
+		DELETE m FROM messages m
+		WHERE NOT EXISTS (SELECT 1 FROM conversation_participants p WHERE p.conversation_id = m.conversation_id)
+	`, 0},
+	{"orphan conversations", `
+		DELETE c FROM conversations c
+		WHERE NOT EXISTS (SELECT 1 FROM conversation_participants p WHERE p.conversation_id = c.id)
+	`, 0},
+
+	// Their archives are listed beforehand and removed by the caller.
+	{"export jobs", `DELETE FROM export_jobs WHERE user_id = ?`, 1},
+	{"import mappings", `DELETE FROM imported_users WHERE user_id = ?`, 1},
+
+	{"user", `DELETE FROM users WHERE id = ?`, 1},
+}
+
+type Repository struct {
+	DB *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// Deleted describes what an account deletion removed, so callers can clean
+// up the data kept outside the database.
+type Deleted struct {
+	// TweetIDs are the removed tweets, to drop from the search index.
+	TweetIDs []int
+	// ExportFiles are the archives of the user's data exports, to remove
+	// from disk once the deletion is committed.
+	ExportFiles []string
+}
+
+// Delete permanently removes the user and everything that references them.
+func (r *Repository) Delete(userID int) (*Deleted, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var deleted Deleted
+	rows, err := tx.Query(`SELECT id FROM tweets WHERE author_id = ?`, userID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		deleted.TweetIDs = append(deleted.TweetIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.Query(`SELECT file_path FROM export_jobs WHERE user_id = ? AND file_path <> ''`, userID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		deleted.ExportFiles = append(deleted.ExportFiles, path)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, s := range deletionSteps {
+		args := make([]interface{}, s.args)
+		for i := range args {
+			args[i] = userID
+		}
+		if _, err := tx.Exec(s.query, args...); err != nil {
+			return nil, &StepError{Step: s.name, Err: err}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &deleted, nil
+}
+
+// StepError tells which stage of the deletion pipeline failed.
+type StepError struct {
+	Step string
+	Err  error
+}
+
+func (e *StepError) Error() string {
+	return "account deletion step " + e.Step + ": " + e.Err.Error()
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,57 @@
+package adminRepo
+
+import (
+	"database/sql"
+	"time"
+	"ualabackend/entities/admin"
+	"ualabackend/entities/moderation"
+)
+
+type Repository struct {
+	DB *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// Stats counts the rows of the main tables. Tweets counts the live tweets,
+// including the hidden ones, and DeletedTweets the ones still within the
+// restore window.
+func (r *Repository) Stats() (*admin.Stats, error) {
+	s := admin.Stats{GeneratedAt: time.Now()}
+	err := r.DB.QueryRow(`
+		SELECT
+			COUNT(*),
+			COALESCE(SUM(deactivated_at IS NULL AND suspended_at IS NULL), 0),
+			COALESCE(SUM(suspended_at IS NOT NULL), 0),
+			COALESCE(SUM(deactivated_at IS NOT NULL), 0)
+		FROM users
+	`).Scan(&s.Users, &s.ActiveUsers, &s.SuspendedUsers, &s.DeactivatedUsers)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.DB.QueryRow(`
+		SELECT
+			COALESCE(SUM(deleted_at IS NULL), 0),
+			COALESCE(SUM(deleted_at IS NOT NULL), 0),
+			COALESCE(SUM(deleted_at IS NULL AND hidden_at IS NOT NULL), 0)
+		FROM tweets
+	`).Scan(&s.Tweets, &s.DeletedTweets, &s.HiddenTweets)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.DB.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM scheduled_tweets),
+			(SELECT COUNT(*) FROM follows),
+			(SELECT COUNT(*) FROM media),
+			(SELECT COUNT(*) FROM reports WHERE status = ?)
+	`, moderation.StatusOpen).Scan(&s.ScheduledTweets, &s.Follows, &s.Media, &s.OpenReports)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
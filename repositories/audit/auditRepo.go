@@ -0,0 +1,98 @@
+package auditRepo
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+	"ualabackend/entities/audit"
+)
+
+const entryColumns = `id, actor_id, action, target_type, target_id, diff, request_id, ip, created_at`
+
+type Repository struct {
+	DB *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// Append adds the entry to the audit log. The log is append-only: entries
+// are never updated nor deleted, not even when the actor or the target is.
+func (r *Repository) Append(e audit.Entry) error {
+	if e.Diff == nil {
+		e.Diff = map[string]audit.Change{}
+	}
+	diff, err := json.Marshal(e.Diff)
+	if err != nil {
+		return err
+	}
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+
+	var actorID sql.NullInt64
+	if e.ActorID != 0 {
+		actorID = sql.NullInt64{Int64: int64(e.ActorID), Valid: true}
+	}
+	_, err = r.DB.Exec(`
+		INSERT INTO audit_log (actor_id, action, target_type, target_id, diff, request_id, ip, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, actorID, e.Action, e.TargetType, e.TargetID, diff, e.RequestID, e.IP, e.CreatedAt)
+	return err
+}
+
+// Query returns the entries matching f, newest first.
+func (r *Repository) Query(f audit.Filter, limit, offset int) ([]audit.Entry, error) {
+	query := `SELECT ` + entryColumns + ` FROM audit_log WHERE TRUE`
+	args := []interface{}{}
+	if f.ActorID != 0 {
+		query += ` AND actor_id = ?`
+		args = append(args, f.ActorID)
+	}
+	if f.Action != "" {
+		query += ` AND action = ?`
+		args = append(args, f.Action)
+	}
+	if f.TargetType != "" {
+		query += ` AND target_type = ?`
+		args = append(args, f.TargetType)
+	}
+	if f.TargetID != 0 {
+		query += ` AND target_id = ?`
+		args = append(args, f.TargetID)
+	}
+	if !f.Since.IsZero() {
+		query += ` AND created_at >= ?`
+		args = append(args, f.Since)
+	}
+	if !f.Until.IsZero() {
+		query += ` AND created_at < ?`
+		args = append(args, f.Until)
+	}
+	query += ` ORDER BY id DESC LIMIT ? OFFSET ?`
+	args = append(args, limit, offset)
+
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []audit.Entry{}
+	for rows.Next() {
+		var e audit.Entry
+		var actorID sql.NullInt64
+		var diff []byte
+		err := rows.Scan(&e.Id, &actorID, &e.Action, &e.TargetType, &e.TargetID, &diff, &e.RequestID, &e.IP, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		e.ActorID = int(actorID.Int64)
+		if err := json.Unmarshal(diff, &e.Diff); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
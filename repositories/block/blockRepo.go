@@ -0,0 +1,155 @@
+package blockRepo
+
+import (
+	"database/sql"
+	"ualabackend/entities/user"
+)
+
+type Repository struct {
+	DB *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// Block makes blockerID block blockedID and removes any follow or pending
+// follow request between them in either direction, keeping the counters in
+// sync.
+func (r *Repository) Block(blockerID, blockedID int) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`INSERT IGNORE INTO blocks (blocker_id, blocked_id) VALUES (?, ?)`, blockerID, blockedID)
+	if err != nil {
+		return err
+	}
+
+	if err := removeFollow(tx, blockerID, blockedID); err != nil {
+		return err
+	}
+	if err := removeFollow(tx, blockedID, blockerID); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM follow_requests
+		WHERE (requester_id = ? AND target_id = ?) OR (requester_id = ? AND target_id = ?)
+	`, blockerID, blockedID, blockedID, blockerID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *Repository) Unblock(blockerID, blockedID int) error {
+	query := `DELETE FROM blocks WHERE blocker_id = ? AND blocked_id = ?`
+	_, err := r.DB.Exec(query, blockerID, blockedID)
+	return err
+}
+
+func (r *Repository) Mute(muterID, mutedID int) error {
+	query := `INSERT IGNORE INTO mutes (muter_id, muted_id) VALUES (?, ?)`
+	_, err := r.DB.Exec(query, muterID, mutedID)
+	return err
+}
+
+func (r *Repository) Unmute(muterID, mutedID int) error {
+	query := `DELETE FROM mutes WHERE muter_id = ? AND muted_id = ?`
+	_, err := r.DB.Exec(query, muterID, mutedID)
+	return err
+}
+
+// GetBlocked lists the accounts blocked by userID, most recent first.
+func (r *Repository) GetBlocked(userID, limit, offset int) ([]user.UserSummary, error) {
+	query := `
+		SELECT u.id, u.name, u.followers_count
+		FROM blocks b JOIN users u ON u.id = b.blocked_id
+		WHERE b.blocker_id = ?
+		ORDER BY b.created_at DESC, u.id DESC
+		LIMIT ? OFFSET ?
+	`
+	return r.summaries(query, userID, limit, offset)
+}
+
+// GetMuted lists the accounts muted by userID, most recent first.
+func (r *Repository) GetMuted(userID, limit, offset int) ([]user.UserSummary, error) {
+	query := `
+		SELECT u.id, u.name, u.followers_count
+		FROM mutes m JOIN users u ON u.id = m.muted_id
+		WHERE m.muter_id = ?
+		ORDER BY m.created_at DESC, u.id DESC
+		LIMIT ? OFFSET ?
+	`
+	return r.summaries(query, userID, limit, offset)
+}
+
+// IsBlocked reports whether either user blocks the other.
+func (r *Repository) IsBlocked(a, b int) (bool, error) {
+	query := `
+		SELECT EXISTS(SELECT 1 FROM blocks
+			WHERE (blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?))
+	`
+	var blocked bool
+	err := r.DB.QueryRow(query, a, b, b, a).Scan(&blocked)
+	return blocked, err
+}
+
+func (r *Repository) summaries(query string, args ...interface{}) ([]user.UserSummary, error) {
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []user.UserSummary{}
+	for rows.Next() {
+		var u user.UserSummary
+		if err := rows.Scan(&u.Id, &u.Name, &u.FollowersCount); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+func removeFollow(tx *sql.Tx, followerID, followedID int) error {
+	result, err := tx.Exec(`DELETE FROM follows WHERE follower_id = ? AND followed_id = ?`, followerID, followedID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil || affected == 0 {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE users SET followers_count = GREATEST(followers_count - 1, 0) WHERE id = ?`, followedID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`UPDATE users SET following_count = GREATEST(following_count - 1, 0) WHERE id = ?`, followerID)
+	if err != nil {
+		return err
+	}
+
+	// Rebuild the denormalized arrays so new tweets stop fanning out.
+	_, err = tx.Exec(`
+		UPDATE users SET followers_id = (
+			SELECT COALESCE(JSON_ARRAYAGG(f.follower_id), JSON_ARRAY()) FROM follows f WHERE f.followed_id = ?
+		) WHERE id = ?
+	`, followedID, followedID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		UPDATE users SET following_id = (
+			SELECT COALESCE(JSON_ARRAYAGG(f.followed_id), JSON_ARRAY()) FROM follows f WHERE f.follower_id = ?
+		) WHERE id = ?
+	`, followerID, followerID)
+	return err
+}
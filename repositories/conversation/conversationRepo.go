@@ -0,0 +1,456 @@
+package conversationRepo
+
+import (
+	"database/sql"
+	"errors"
+	"sort"
+	"strconv"
+	"ualabackend/entities/conversation"
+)
+
+// MaxParticipants is the size limit of group conversations, creator included.
+const MaxParticipants = 10
+
+var (
+	// ErrNotParticipant is returned when the user is not part of the conversation.
+	ErrNotParticipant = errors.New("user is not a participant of the conversation")
+	// ErrNotAllowed is returned when the DM policy forbids messaging a participant.
+	ErrNotAllowed = errors.New("direct messages are not allowed between these users")
+	// ErrTooManyParticipants is returned when a group would exceed MaxParticipants.
+	ErrTooManyParticipants = errors.New("too many participants")
+)
+
+type Repository struct {
+	DB *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// Create starts a conversation between creatorID and participantIDs. For a
+// single participant the existing 1:1 conversation is reused if there is one;
+// the unique pair key makes concurrent requests for the same pair end up in
+// the same conversation.
+func (r *Repository) Create(creatorID int, participantIDs []int) (*conversation.Conversation, error) {
+	others := []int{}
+	seen := map[int]bool{creatorID: true}
+	for _, id := range participantIDs {
+		if !seen[id] {
+			seen[id] = true
+			others = append(others, id)
+		}
+	}
+	if len(others) == 0 {
+		return nil, ErrNotAllowed
+	}
+	if len(others)+1 > MaxParticipants {
+		return nil, ErrTooManyParticipants
+	}
+
+	for _, id := range others {
+		allowed, err := r.canMessage(creatorID, id)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, ErrNotAllowed
+		}
+	}
+
+	isGroup := len(others) > 1
+	var key interface{}
+	if !isGroup {
+		key = pairKey(creatorID, others[0])
+		existing, err := r.byPairKey(key.(string))
+		if err != nil {
+			return nil, err
+		}
+		if existing != 0 {
+			return r.Get(existing, creatorID)
+		}
+	}
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`INSERT IGNORE INTO conversations (is_group, pair_key) VALUES (?, ?)`, isGroup, key)
+	if err != nil {
+		return nil, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if affected == 0 {
+		// Another request created the conversation for this pair meanwhile.
+		tx.Rollback()
+		existing, err := r.byPairKey(key.(string))
+		if err != nil {
+			return nil, err
+		}
+		return r.Get(existing, creatorID)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, userID := range append([]int{creatorID}, others...) {
+		_, err := tx.Exec(`INSERT INTO conversation_participants (conversation_id, user_id) VALUES (?, ?)`, id, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.Get(int(id), creatorID)
+}
+
+// pairKey identifies the 1:1 conversation between two users regardless of
+// who started it.
+func pairKey(a, b int) string {
+	if a > b {
+		a, b = b, a
+	}
+	return strconv.Itoa(a) + ":" + strconv.Itoa(b)
+}
+
+// byPairKey returns the ID of the 1:1 conversation with the given key, or 0
+// when there is none.
+func (r *Repository) byPairKey(key string) (int, error) {
+	var id int
+	err := r.DB.QueryRow(`SELECT id FROM conversations WHERE pair_key = ?`, key).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return id, err
+}
+
+// Get returns the conversation as seen by userID.
+func (r *Repository) Get(id, userID int) (*conversation.Conversation, error) {
+	if err := r.checkParticipant(id, userID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT c.id, c.is_group, c.created_at, (
+			SELECT COUNT(*) FROM messages m
+			WHERE m.conversation_id = c.id AND m.sender_id <> p.user_id
+				AND m.id > p.last_read_message_id AND m.id > p.cleared_before_message_id
+				AND NOT EXISTS (SELECT 1 FROM message_deletions d WHERE d.message_id = m.id AND d.user_id = p.user_id)
+		)
+		FROM conversations c
+		JOIN conversation_participants p ON p.conversation_id = c.id AND p.user_id = ?
+		WHERE c.id = ?
+	`
+	var conv conversation.Conversation
+	err := r.DB.QueryRow(query, userID, id).Scan(&conv.Id, &conv.IsGroup, &conv.CreatedAt, &conv.UnreadCount)
+	if err != nil {
+		return nil, err
+	}
+
+	conv.ParticipantIDs, err = r.participantIDs(id)
+	if err != nil {
+		return nil, err
+	}
+	return &conv, nil
+}
+
+// List returns the conversations of userID, the most recently active first.
+func (r *Repository) List(userID, limit, offset int) ([]conversation.Conversation, error) {
+	query := `
+		SELECT p.conversation_id
+		FROM conversation_participants p
+		WHERE p.user_id = ?
+		ORDER BY (SELECT COALESCE(MAX(m.id), 0) FROM messages m WHERE m.conversation_id = p.conversation_id) DESC,
+			p.conversation_id DESC
+		LIMIT ? OFFSET ?
+	`
+	rows, err := r.DB.Query(query, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	conversations := []conversation.Conversation{}
+	for _, id := range ids {
+		conv, err := r.Get(id, userID)
+		if err != nil {
+			return nil, err
+		}
+		conversations = append(conversations, *conv)
+	}
+	return conversations, nil
+}
+
+// Send adds a message from senderID, enforcing the DM policy against every
+// other participant.
+func (r *Repository) Send(conversationID, senderID int, body string) (*conversation.Message, error) {
+	if err := r.checkParticipant(conversationID, senderID); err != nil {
+		return nil, err
+	}
+
+	participants, err := r.participantIDs(conversationID)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range participants {
+		if id == senderID {
+			continue
+		}
+		allowed, err := r.canMessage(senderID, id)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, ErrNotAllowed
+		}
+	}
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`INSERT INTO messages (conversation_id, sender_id, body) VALUES (?, ?, ?)`, conversationID, senderID, body)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	// Sending a message implies having read everything before it.
+	_, err = tx.Exec(`
+		UPDATE conversation_participants SET last_read_message_id = ?
+		WHERE conversation_id = ? AND user_id = ?
+	`, id, conversationID, senderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	m := conversation.Message{Id: int(id), ConversationID: conversationID, SenderID: senderID, Body: body, ReadBy: []int{}}
+	err = r.DB.QueryRow(`SELECT created_at FROM messages WHERE id = ?`, id).Scan(&m.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// History returns the messages visible to userID, newest first, leaving out
+// those of suspended senders. beforeID is an exclusive cursor; 0 starts from
+// the latest message.
+func (r *Repository) History(conversationID, userID, beforeID, limit int) ([]conversation.Message, error) {
+	if err := r.checkParticipant(conversationID, userID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT m.id, m.conversation_id, m.sender_id, m.body, m.created_at
+		FROM messages m
+		JOIN conversation_participants p ON p.conversation_id = m.conversation_id AND p.user_id = ?
+		WHERE m.conversation_id = ?
+			AND m.id > p.cleared_before_message_id
+			AND (? = 0 OR m.id < ?)
+			AND NOT EXISTS (SELECT 1 FROM message_deletions d WHERE d.message_id = m.id AND d.user_id = ?)
+			AND (SELECT s.suspended_at FROM users s WHERE s.id = m.sender_id) IS NULL
+		ORDER BY m.id DESC
+		LIMIT ?
+	`
+	rows, err := r.DB.Query(query, userID, conversationID, beforeID, beforeID, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []conversation.Message{}
+	for rows.Next() {
+		var m conversation.Message
+		if err := rows.Scan(&m.Id, &m.ConversationID, &m.SenderID, &m.Body, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	lastRead, err := r.lastRead(conversationID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range messages {
+		messages[i].ReadBy = []int{}
+		for id, last := range lastRead {
+			if id != messages[i].SenderID && last >= messages[i].Id {
+				messages[i].ReadBy = append(messages[i].ReadBy, id)
+			}
+		}
+		sort.Ints(messages[i].ReadBy)
+	}
+	return messages, nil
+}
+
+// MarkRead records that userID has read up to messageID, or up to the latest
+// message when messageID is 0.
+func (r *Repository) MarkRead(conversationID, userID, messageID int) error {
+	if err := r.checkParticipant(conversationID, userID); err != nil {
+		return err
+	}
+
+	if messageID == 0 {
+		err := r.DB.QueryRow(`SELECT COALESCE(MAX(id), 0) FROM messages WHERE conversation_id = ?`, conversationID).Scan(&messageID)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := r.DB.Exec(`
+		UPDATE conversation_participants SET last_read_message_id = GREATEST(last_read_message_id, ?)
+		WHERE conversation_id = ? AND user_id = ?
+	`, messageID, conversationID, userID)
+	return err
+}
+
+// DeleteMessage hides a message for userID only. found is false when the
+// message does not belong to the conversation.
+func (r *Repository) DeleteMessage(conversationID, messageID, userID int) (found bool, err error) {
+	if err := r.checkParticipant(conversationID, userID); err != nil {
+		return false, err
+	}
+
+	result, err := r.DB.Exec(`
+		INSERT IGNORE INTO message_deletions (message_id, user_id)
+		SELECT id, ? FROM messages WHERE id = ? AND conversation_id = ?
+	`, userID, messageID, conversationID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected > 0 {
+		return true, nil
+	}
+
+	var exists bool
+	err = r.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM messages WHERE id = ? AND conversation_id = ?)`, messageID, conversationID).Scan(&exists)
+	return exists, err
+}
+
+// Clear hides the current history of the conversation for userID only. New
+// messages will still be delivered.
+func (r *Repository) Clear(conversationID, userID int) error {
+	if err := r.checkParticipant(conversationID, userID); err != nil {
+		return err
+	}
+
+	_, err := r.DB.Exec(`
+		UPDATE conversation_participants
+		SET cleared_before_message_id = (SELECT COALESCE(MAX(m.id), 0) FROM messages m WHERE m.conversation_id = ?)
+		WHERE conversation_id = ? AND user_id = ?
+	`, conversationID, conversationID, userID)
+	return err
+}
+
+// canMessage applies the DM policy: an active sender, no block in either
+// direction, and either a mutual follow or a recipient open to DMs from
+// anyone.
+func (r *Repository) canMessage(senderID, recipientID int) (bool, error) {
+	query := `
+		SELECT
+			(SELECT COALESCE(s.suspended_at, s.deactivated_at) FROM users s WHERE s.id = ?) IS NULL
+			AND NOT EXISTS (SELECT 1 FROM blocks b
+				WHERE (b.blocker_id = ? AND b.blocked_id = u.id) OR (b.blocker_id = u.id AND b.blocked_id = ?))
+			AND (u.allow_dms_from_anyone OR (
+				EXISTS (SELECT 1 FROM follows f WHERE f.follower_id = ? AND f.followed_id = u.id)
+				AND EXISTS (SELECT 1 FROM follows f WHERE f.follower_id = u.id AND f.followed_id = ?)))
+		FROM users u WHERE u.id = ?
+	`
+	var allowed bool
+	err := r.DB.QueryRow(query, senderID, senderID, senderID, senderID, senderID, recipientID).Scan(&allowed)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return allowed, err
+}
+
+func (r *Repository) checkParticipant(conversationID, userID int) error {
+	var ok bool
+	err := r.DB.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM conversation_participants WHERE conversation_id = ? AND user_id = ?)
+	`, conversationID, userID).Scan(&ok)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotParticipant
+	}
+	return nil
+}
+
+func (r *Repository) participantIDs(conversationID int) ([]int, error) {
+	rows, err := r.DB.Query(`
+		SELECT user_id FROM conversation_participants WHERE conversation_id = ? ORDER BY user_id
+	`, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *Repository) lastRead(conversationID int) (map[int]int, error) {
+	rows, err := r.DB.Query(`
+		SELECT user_id, last_read_message_id FROM conversation_participants WHERE conversation_id = ?
+	`, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lastRead := map[int]int{}
+	for rows.Next() {
+		var id, last int
+		if err := rows.Scan(&id, &last); err != nil {
+			return nil, err
+		}
+		lastRead[id] = last
+	}
+	return lastRead, rows.Err()
+}
@@ -0,0 +1,80 @@
+package draftRepo
+
+import (
+	"database/sql"
+	"time"
+	"ualabackend/entities/draft"
+)
+
+const draftColumns = `id, user_id, message, created_at, updated_at`
+
+type Repository struct {
+	DB *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+func (r *Repository) Create(userID int, message string) (*draft.Draft, error) {
+	now := time.Now()
+	result, err := r.DB.Exec(`
+		INSERT INTO drafts (user_id, message, created_at, updated_at) VALUES (?, ?, ?, ?)
+	`, userID, message, now, now)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(int(id))
+}
+
+func (r *Repository) GetByID(id int) (*draft.Draft, error) {
+	row := r.DB.QueryRow(`SELECT `+draftColumns+` FROM drafts WHERE id = ?`, id)
+
+	var d draft.Draft
+	err := row.Scan(&d.Id, &d.UserID, &d.Message, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &d, nil
+}
+
+// GetByUser returns the user's drafts, the most recently edited first.
+func (r *Repository) GetByUser(userID, limit, offset int) ([]draft.Draft, error) {
+	rows, err := r.DB.Query(`
+		SELECT `+draftColumns+` FROM drafts WHERE user_id = ?
+		ORDER BY updated_at DESC, id DESC
+		LIMIT ? OFFSET ?
+	`, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	drafts := []draft.Draft{}
+	for rows.Next() {
+		var d draft.Draft
+		if err := rows.Scan(&d.Id, &d.UserID, &d.Message, &d.CreatedAt, &d.UpdatedAt); err != nil {
+			return nil, err
+		}
+		drafts = append(drafts, d)
+	}
+	return drafts, rows.Err()
+}
+
+func (r *Repository) Update(id int, message string) error {
+	_, err := r.DB.Exec(`UPDATE drafts SET message = ?, updated_at = ? WHERE id = ?`, message, time.Now(), id)
+	return err
+}
+
+func (r *Repository) Delete(id int) error {
+	_, err := r.DB.Exec(`DELETE FROM drafts WHERE id = ?`, id)
+	return err
+}
@@ -0,0 +1,160 @@
+package exportRepo
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"time"
+	"ualabackend/entities/export"
+)
+
+const jobColumns = `id, user_id, status, error, created_at, completed_at, expires_at, file_path`
+
+// ErrInProgress is returned when the user already has a pending or running
+// export.
+var ErrInProgress = errors.New("an export is already in progress")
+
+type Repository struct {
+	DB *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// Create registers a pending export for userID. Job IDs are random so they
+// cannot be guessed from another user's. It returns ErrInProgress when the
+// user already has an export pending or running.
+func (r *Repository) Create(userID int) (*export.Job, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	id := hex.EncodeToString(buf)
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Locking the user serializes concurrent requests of the same user.
+	if _, err := tx.Exec(`SELECT id FROM users WHERE id = ? FOR UPDATE`, userID); err != nil {
+		return nil, err
+	}
+	var inProgress bool
+	err = tx.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM export_jobs WHERE user_id = ? AND status IN (?, ?))
+	`, userID, export.StatusPending, export.StatusRunning).Scan(&inProgress)
+	if err != nil {
+		return nil, err
+	}
+	if inProgress {
+		return nil, ErrInProgress
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO export_jobs (id, user_id, status, created_at) VALUES (?, ?, ?, ?)
+	`, id, userID, export.StatusPending, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
+}
+
+func (r *Repository) GetByID(id string) (*export.Job, error) {
+	row := r.DB.QueryRow(`SELECT `+jobColumns+` FROM export_jobs WHERE id = ?`, id)
+	job, err := scanJob(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Unfinished returns the jobs that were pending or running, e.g. when the
+// process restarted in the middle of an export.
+func (r *Repository) Unfinished() ([]export.Job, error) {
+	rows, err := r.DB.Query(`
+		SELECT `+jobColumns+` FROM export_jobs WHERE status IN (?, ?) ORDER BY created_at
+	`, export.StatusPending, export.StatusRunning)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanJobs(rows)
+}
+
+// ExpiredBefore returns finished jobs whose archive expired before now.
+func (r *Repository) ExpiredBefore(now time.Time) ([]export.Job, error) {
+	rows, err := r.DB.Query(`
+		SELECT `+jobColumns+` FROM export_jobs WHERE status = ? AND expires_at < ?
+	`, export.StatusDone, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanJobs(rows)
+}
+
+func (r *Repository) MarkRunning(id string) error {
+	_, err := r.DB.Exec(`UPDATE export_jobs SET status = ? WHERE id = ?`, export.StatusRunning, id)
+	return err
+}
+
+func (r *Repository) MarkDone(id, path string, expiresAt time.Time) error {
+	_, err := r.DB.Exec(`
+		UPDATE export_jobs SET status = ?, file_path = ?, completed_at = ?, expires_at = ? WHERE id = ?
+	`, export.StatusDone, path, time.Now(), expiresAt, id)
+	return err
+}
+
+func (r *Repository) MarkFailed(id string, cause error) error {
+	_, err := r.DB.Exec(`
+		UPDATE export_jobs SET status = ?, error = ?, completed_at = ? WHERE id = ?
+	`, export.StatusFailed, cause.Error(), time.Now(), id)
+	return err
+}
+
+func (r *Repository) MarkExpired(id string) error {
+	_, err := r.DB.Exec(`UPDATE export_jobs SET status = ?, file_path = '' WHERE id = ?`, export.StatusExpired, id)
+	return err
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanJob(s scanner) (export.Job, error) {
+	var job export.Job
+	var completedAt, expiresAt sql.NullTime
+	err := s.Scan(&job.Id, &job.UserID, &job.Status, &job.Error, &job.CreatedAt, &completedAt, &expiresAt, &job.FilePath)
+	if err != nil {
+		return job, err
+	}
+	if completedAt.Valid {
+		job.CompletedAt = &completedAt.Time
+	}
+	if expiresAt.Valid {
+		job.ExpiresAt = &expiresAt.Time
+	}
+	return job, nil
+}
+
+func scanJobs(rows *sql.Rows) ([]export.Job, error) {
+	jobs := []export.Job{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
@@ -2,11 +2,15 @@ package followRepo
 
 import (
 	"database/sql"
-	"fmt"
-	"time"
+	"errors"
 	"ualabackend/entities/follow"
+	"ualabackend/entities/user"
 )
 
+// ErrBlocked is returned when a follow is attempted between two users where
+// one of them blocks the other.
+var ErrBlocked = errors.New("one of the users blocks the other")
+
 type Repository struct {
 	DB *sql.DB
 }
@@ -16,51 +20,129 @@ func NewRepository(db *sql.DB) *Repository {
 }
 
 func (r *Repository) Create(followerID, followedID int) error {
-	_, err := r.DB.Exec("INSERT INTO follows (follower_id, followed_id) VALUES (?, ?)", followerID, followedID)
+	tx, err := r.DB.Begin()
 	if err != nil {
-		fmt.Println("Error en INSERT:", err)
 		return err
 	}
+	defer tx.Rollback()
 
-	_, err = r.DB.Exec(`
-		UPDATE users SET followers_id = JSON_ARRAY() WHERE id = ? AND followers_id IS NULL
-	`, followedID)
-	if err != nil {
-		fmt.Println("Error inicializando followers_id:", err)
+	if err := checkNotBlocked(tx, followerID, followedID); err != nil {
 		return err
 	}
 
-	_, err = r.DB.Exec(`
-		UPDATE users SET following_id = JSON_ARRAY() WHERE id = ? AND following_id IS NULL
-	`, followerID)
-	if err != nil {
-		fmt.Println("Error inicializando following_id:", err)
+	if err := insertFollow(tx, followerID, followedID); err != nil {
 		return err
 	}
 
-	_, err = r.DB.Exec(`
-		UPDATE users SET followers_id = JSON_ARRAY_APPEND(followers_id, '$', ?) WHERE id = ?
+	return tx.Commit()
+}
+
+// Request follows followedID right away, or files a pending follow request
+// when the account is protected. pending reports which of the two happened.
+func (r *Repository) Request(followerID, followedID int) (pending bool, err error) {
+	var protected bool
+	err = r.DB.QueryRow(`SELECT protected FROM users WHERE id = ?`, followedID).Scan(&protected)
+	if err != nil {
+		return false, err
+	}
+	if !protected {
+		return false, r.Create(followerID, followedID)
+	}
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if err := checkNotBlocked(tx, followerID, followedID); err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec(`
+		INSERT IGNORE INTO follow_requests (requester_id, target_id) VALUES (?, ?)
 	`, followerID, followedID)
 	if err != nil {
-		fmt.Println("Error en UPDATE followers_id:", err)
-		return err
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// GetRequests lists the pending follow requests received by userID, oldest
+// first.
+func (r *Repository) GetRequests(userID, limit, offset int) ([]follow.FollowRequest, error) {
+	query := `
+		SELECT fr.requester_id, u.name, fr.target_id, fr.created_at
+		FROM follow_requests fr JOIN users u ON u.id = fr.requester_id
+		WHERE fr.target_id = ?
+		ORDER BY fr.created_at ASC, fr.requester_id ASC
+		LIMIT ? OFFSET ?
+	`
+	rows, err := r.DB.Query(query, userID, limit, offset)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	_, err = r.DB.Exec(`
-		UPDATE users SET following_id = JSON_ARRAY_APPEND(following_id, '$', ?) WHERE id = ?
-	`, followedID, followerID)
+	requests := []follow.FollowRequest{}
+	for rows.Next() {
+		var fr follow.FollowRequest
+		if err := rows.Scan(&fr.RequesterID, &fr.RequesterName, &fr.TargetID, &fr.CreatedAt); err != nil {
+			return nil, err
+		}
+		requests = append(requests, fr)
+	}
+	return requests, rows.Err()
+}
+
+// ApproveRequest turns a pending request into a follow. found is false when
+// there was no such request.
+func (r *Repository) ApproveRequest(targetID, requesterID int) (found bool, err error) {
+	tx, err := r.DB.Begin()
 	if err != nil {
-		fmt.Println("Error en UPDATE following_id:", err)
-		return err
+		return false, err
 	}
+	defer tx.Rollback()
 
-	fmt.Println("Follow creado con éxito")
-	return nil
+	result, err := tx.Exec(`DELETE FROM follow_requests WHERE requester_id = ? AND target_id = ?`, requesterID, targetID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil || affected == 0 {
+		return false, err
+	}
+
+	if err := checkNotBlocked(tx, requesterID, targetID); err != nil {
+		return true, err
+	}
+	if err := insertFollow(tx, requesterID, targetID); err != nil {
+		return true, err
+	}
+	return true, tx.Commit()
 }
 
+// RejectRequest discards a pending request. found is false when there was no
+// such request.
+func (r *Repository) RejectRequest(targetID, requesterID int) (found bool, err error) {
+	result, err := r.DB.Exec(`DELETE FROM follow_requests WHERE requester_id = ? AND target_id = ?`, requesterID, targetID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// activeEnds joins both users of a follow and keeps the follows between
+// accounts that are neither suspended nor deactivated, as GetFollowers does.
+const activeEnds = `
+	JOIN users a ON a.id = f.follower_id
+	JOIN users b ON b.id = f.followed_id
+	WHERE a.suspended_at IS NULL AND a.deactivated_at IS NULL
+		AND b.suspended_at IS NULL AND b.deactivated_at IS NULL`
 
 func (r *Repository) GetAll() ([]follow.Follow, error) {
-	query := `SELECT follower_id, followed_id FROM follows`
+	query := `SELECT f.follower_id, f.followed_id, f.created_at FROM follows f ` + activeEnds
 	rows, err := r.DB.Query(query)
 	if err != nil {
 		return nil, err
@@ -70,10 +152,9 @@ func (r *Repository) GetAll() ([]follow.Follow, error) {
 	var follows []follow.Follow
 	for rows.Next() {
 		var f follow.Follow
-		if err := rows.Scan(&f.FollowerID, &f.FollowedID); err != nil {
+		if err := rows.Scan(&f.FollowerID, &f.FollowedID, &f.CreatedAt); err != nil {
 			return nil, err
 		}
-		f.Timestamp = time.Now() // opcional: si querés mostrar hora actual
 		follows = append(follows, f)
 	}
 
@@ -81,28 +162,57 @@ func (r *Repository) GetAll() ([]follow.Follow, error) {
 }
 
 func (r *Repository) GetByIDs(followerID, followedID int) (*follow.Follow, error) {
-	query := `SELECT follower_id, followed_id FROM follows WHERE follower_id = ? AND followed_id = ?`
+	query := `SELECT f.follower_id, f.followed_id, f.created_at FROM follows f ` + activeEnds + `
+		AND f.follower_id = ? AND f.followed_id = ?`
 	row := r.DB.QueryRow(query, followerID, followedID)
 
 	var f follow.Follow
-	if err := row.Scan(&f.FollowerID, &f.FollowedID); err != nil {
+	if err := row.Scan(&f.FollowerID, &f.FollowedID, &f.CreatedAt); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, err
 	}
-	f.Timestamp = time.Now()
 	return &f, nil
 }
 
-func (r *Repository) Delete(followerID, followedID int) error {
+// Delete removes the follow, if any, and reports whether there was one.
+func (r *Repository) Delete(followerID, followedID int) (found bool, err error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
 	query := `DELETE FROM follows WHERE follower_id = ? AND followed_id = ?`
-	_, err := r.DB.Exec(query, followerID, followedID)
-	return err
+	result, err := tx.Exec(query, followerID, followedID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected == 0 {
+		return false, tx.Commit()
+	}
+
+	_, err = tx.Exec(`UPDATE users SET followers_count = GREATEST(followers_count - 1, 0) WHERE id = ?`, followedID)
+	if err != nil {
+		return false, err
+	}
+	_, err = tx.Exec(`UPDATE users SET following_count = GREATEST(following_count - 1, 0) WHERE id = ?`, followerID)
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
 }
 
 func (r *Repository) GetFollowedByFollowerID(followerID int) ([]follow.Follow, error) {
-	query := `SELECT follower_id, followed_id FROM follows WHERE follower_id = ?`
+	query := `SELECT f.follower_id, f.followed_id, f.created_at FROM follows f ` + activeEnds + `
+		AND f.follower_id = ?`
 	rows, err := r.DB.Query(query, followerID)
 	if err != nil {
 		return nil, err
@@ -112,11 +222,242 @@ func (r *Repository) GetFollowedByFollowerID(followerID int) ([]follow.Follow, e
 	var follows []follow.Follow
 	for rows.Next() {
 		var f follow.Follow
-		if err := rows.Scan(&f.FollowerID, &f.FollowedID); err != nil {
+		if err := rows.Scan(&f.FollowerID, &f.FollowedID, &f.CreatedAt); err != nil {
+			return nil, err
+		}
+		follows = append(follows, f)
+	}
+	return follows, nil
+}
+
+func (r *Repository) GetFollowersByFollowedID(followedID int) ([]follow.Follow, error) {
+	query := `SELECT follower_id, followed_id, created_at FROM follows WHERE followed_id = ?`
+	rows, err := r.DB.Query(query, followedID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var follows []follow.Follow
+	for rows.Next() {
+		var f follow.Follow
+		if err := rows.Scan(&f.FollowerID, &f.FollowedID, &f.CreatedAt); err != nil {
 			return nil, err
 		}
-		f.Timestamp = time.Now() // opcional
 		follows = append(follows, f)
 	}
 	return follows, nil
 }
+
+// GetFollowers returns the users following userID, most recent first.
+// viewerID is used to flag which of them the viewer already follows.
+func (r *Repository) GetFollowers(userID, viewerID, limit, offset int) ([]user.UserSummary, error) {
+	query := `
+		SELECT u.id, u.name, u.followers_count,
+			EXISTS(SELECT 1 FROM follows v WHERE v.follower_id = ? AND v.followed_id = u.id)
+		FROM follows f JOIN users u ON u.id = f.follower_id
+		WHERE f.followed_id = ? AND u.deactivated_at IS NULL AND u.suspended_at IS NULL
+		ORDER BY f.created_at DESC, f.id DESC
+		LIMIT ? OFFSET ?
+	`
+	return r.summaries(query, viewerID, userID, limit, offset)
+}
+
+// GetFollowing returns the users followed by userID, most recent first.
+func (r *Repository) GetFollowing(userID, viewerID, limit, offset int) ([]user.UserSummary, error) {
+	query := `
+		SELECT u.id, u.name, u.followers_count,
+			EXISTS(SELECT 1 FROM follows v WHERE v.follower_id = ? AND v.followed_id = u.id)
+		FROM follows f JOIN users u ON u.id = f.followed_id
+		WHERE f.follower_id = ? AND u.deactivated_at IS NULL AND u.suspended_at IS NULL
+		ORDER BY f.created_at DESC, f.id DESC
+		LIMIT ? OFFSET ?
+	`
+	return r.summaries(query, viewerID, userID, limit, offset)
+}
+
+// GetRelationship reports every link between sourceID and targetID in a
+// single query.
+func (r *Repository) GetRelationship(sourceID, targetID int) (*follow.Relationship, error) {
+	query := `
+		SELECT
+			EXISTS(SELECT 1 FROM follows WHERE follower_id = ? AND followed_id = ?),
+			EXISTS(SELECT 1 FROM follows WHERE follower_id = ? AND followed_id = ?),
+			EXISTS(SELECT 1 FROM blocks WHERE blocker_id = ? AND blocked_id = ?),
+			EXISTS(SELECT 1 FROM blocks WHERE blocker_id = ? AND blocked_id = ?)
+	`
+	rel := follow.Relationship{SourceID: sourceID, TargetID: targetID}
+	err := r.DB.QueryRow(query,
+		sourceID, targetID,
+		targetID, sourceID,
+		sourceID, targetID,
+		targetID, sourceID,
+	).Scan(&rel.Following, &rel.FollowedBy, &rel.Blocking, &rel.BlockedBy)
+	if err != nil {
+		return nil, err
+	}
+	rel.Mutual = rel.Following && rel.FollowedBy
+	return &rel, nil
+}
+
+func (r *Repository) summaries(query string, args ...interface{}) ([]user.UserSummary, error) {
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []user.UserSummary{}
+	for rows.Next() {
+		var u user.UserSummary
+		if err := rows.Scan(&u.Id, &u.Name, &u.FollowersCount, &u.FollowedByViewer); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, nil
+}
+
+// FriendsOfFriends returns the accounts followed by the people userID follows,
+// ranked by how many of them follow each account. Already followed, blocked
+// and reach-restricted accounts are excluded.
+func (r *Repository) FriendsOfFriends(userID, limit int) ([]follow.Suggestion, error) {
+	query := `
+		SELECT u.id, u.name, u.followers_count, COUNT(*) AS mutual
+		FROM follows f1
+		JOIN follows f2 ON f2.follower_id = f1.followed_id
+		JOIN users u ON u.id = f2.followed_id
+		WHERE f1.follower_id = ?
+			AND u.id <> ? AND u.deactivated_at IS NULL AND u.suspended_at IS NULL AND NOT u.reach_restricted
+			AND NOT EXISTS (SELECT 1 FROM follows x WHERE x.follower_id = ? AND x.followed_id = u.id)
+			AND NOT EXISTS (SELECT 1 FROM blocks b
+				WHERE (b.blocker_id = ? AND b.blocked_id = u.id) OR (b.blocker_id = u.id AND b.blocked_id = ?))
+		GROUP BY u.id, u.name, u.followers_count
+		ORDER BY mutual DESC, u.followers_count DESC, u.id ASC
+		LIMIT ?
+	`
+	return r.suggestions(query, follow.ReasonFriendsOfFriends, userID, userID, userID, userID, userID, limit)
+}
+
+// Popular returns the most followed accounts that userID does not follow yet,
+// used as a cold-start fallback. Reach-restricted accounts are excluded.
+func (r *Repository) Popular(userID, limit int) ([]follow.Suggestion, error) {
+	query := `
+		SELECT u.id, u.name, u.followers_count, 0
+		FROM users u
+		WHERE u.id <> ? AND u.deactivated_at IS NULL AND u.suspended_at IS NULL AND NOT u.reach_restricted
+			AND NOT EXISTS (SELECT 1 FROM follows x WHERE x.follower_id = ? AND x.followed_id = u.id)
+			AND NOT EXISTS (SELECT 1 FROM blocks b
+				WHERE (b.blocker_id = ? AND b.blocked_id = u.id) OR (b.blocker_id = u.id AND b.blocked_id = ?))
+		ORDER BY u.followers_count DESC, u.id ASC
+		LIMIT ?
+	`
+	return r.suggestions(query, follow.ReasonPopular, userID, userID, userID, userID, limit)
+}
+
+// ExcludedIDs returns the accounts that must never be suggested to userID:
+// the ones already followed and the ones blocked in either direction.
+func (r *Repository) ExcludedIDs(userID int) (map[int]bool, error) {
+	query := `
+		SELECT followed_id FROM follows WHERE follower_id = ?
+		UNION SELECT blocked_id FROM blocks WHERE blocker_id = ?
+		UNION SELECT blocker_id FROM blocks WHERE blocked_id = ?
+	`
+	rows, err := r.DB.Query(query, userID, userID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	excluded := map[int]bool{userID: true}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		excluded[id] = true
+	}
+	return excluded, rows.Err()
+}
+
+func (r *Repository) suggestions(query, reason string, args ...interface{}) ([]follow.Suggestion, error) {
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suggestions := []follow.Suggestion{}
+	for rows.Next() {
+		s := follow.Suggestion{Reason: reason}
+		if err := rows.Scan(&s.User.Id, &s.User.Name, &s.User.FollowersCount, &s.MutualCount); err != nil {
+			return nil, err
+		}
+		suggestions = append(suggestions, s)
+	}
+	return suggestions, rows.Err()
+}
+
+// insertFollow stores the follow and updates the denormalized arrays and
+// counters of both users.
+func insertFollow(tx *sql.Tx, followerID, followedID int) error {
+	_, err := tx.Exec("INSERT INTO follows (follower_id, followed_id) VALUES (?, ?)", followerID, followedID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE users SET followers_id = JSON_ARRAY() WHERE id = ? AND followers_id IS NULL
+	`, followedID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE users SET following_id = JSON_ARRAY() WHERE id = ? AND following_id IS NULL
+	`, followerID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE users SET followers_id = JSON_ARRAY_APPEND(followers_id, '$', ?) WHERE id = ?
+	`, followerID, followedID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE users SET following_id = JSON_ARRAY_APPEND(following_id, '$', ?) WHERE id = ?
+	`, followedID, followerID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE users SET followers_count = followers_count + 1 WHERE id = ?`, followedID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE users SET following_count = following_count + 1 WHERE id = ?`, followerID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func checkNotBlocked(tx *sql.Tx, followerID, followedID int) error {
+	var blocked bool
+	err := tx.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM blocks
+			WHERE (blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?))
+	`, followerID, followedID, followedID, followerID).Scan(&blocked)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrBlocked
+	}
+	return nil
+}
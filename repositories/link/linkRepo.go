@@ -0,0 +1,72 @@
+package linkRepo
+
+import (
+	"database/sql"
+	"strings"
+	"ualabackend/entities/link"
+	"ualabackend/links"
+)
+
+type Repository struct {
+	DB *sql.DB
+	// Base is the public address short links are served from.
+	Base string
+}
+
+func NewRepository(db *sql.DB, base string) *Repository {
+	return &Repository{DB: db, Base: base}
+}
+
+// Follow counts a click on the short link and returns its target, or "" when
+// the code does not exist.
+func (r *Repository) Follow(code string) (string, error) {
+	result, err := r.DB.Exec(`UPDATE links SET clicks = clicks + 1 WHERE code = ?`, code)
+	if err != nil {
+		return "", err
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return "", err
+	}
+
+	var url string
+	err = r.DB.QueryRow(`SELECT url FROM links WHERE code = ?`, code).Scan(&url)
+	return url, err
+}
+
+// ForTweets returns the link entities of each of the given tweets, in the
+// order they appear in the message.
+func (r *Repository) ForTweets(tweetIDs []int) (map[int][]link.Link, error) {
+	result := map[int][]link.Link{}
+	if len(tweetIDs) == 0 {
+		return result, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(tweetIDs)), ",")
+	args := make([]interface{}, len(tweetIDs))
+	for i, id := range tweetIDs {
+		args[i] = id
+	}
+	rows, err := r.DB.Query(`
+		SELECT tl.tweet_id, tl.code, l.url, tl.start_index, tl.end_index
+		FROM tweet_links tl JOIN links l ON l.code = tl.code
+		WHERE tl.tweet_id IN (`+placeholders+`)
+		ORDER BY tl.tweet_id, tl.position
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tweetID int
+		var code string
+		var l link.Link
+		if err := rows.Scan(&tweetID, &code, &l.ExpandedURL, &l.Indices[0], &l.Indices[1]); err != nil {
+			return nil, err
+		}
+		l.URL = r.Base + "/l/" + code
+		l.DisplayURL = links.Display(l.ExpandedURL)
+		result[tweetID] = append(result[tweetID], l)
+	}
+	return result, rows.Err()
+}
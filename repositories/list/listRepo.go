@@ -0,0 +1,162 @@
+package listRepo
+
+import (
+	"database/sql"
+	"ualabackend/entities/list"
+	"ualabackend/entities/user"
+)
+
+const selectList = `
+	SELECT l.id, l.owner_id, l.name, l.description, l.private, l.created_at,
+		(SELECT COUNT(*) FROM list_members m WHERE m.list_id = l.id),
+		(SELECT COUNT(*) FROM list_subscribers s WHERE s.list_id = l.id)
+	FROM lists l
+`
+
+type Repository struct {
+	DB *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+func (r *Repository) Create(ownerID int, input list.ListInput) (*list.List, error) {
+	result, err := r.DB.Exec(`
+		INSERT INTO lists (owner_id, name, description, private) VALUES (?, ?, ?, ?)
+	`, ownerID, input.Name, input.Description, input.Private)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(int(id))
+}
+
+func (r *Repository) GetByID(id int) (*list.List, error) {
+	row := r.DB.QueryRow(selectList+` WHERE l.id = ?`, id)
+
+	var l list.List
+	err := row.Scan(&l.Id, &l.OwnerID, &l.Name, &l.Description, &l.Private, &l.CreatedAt, &l.MemberCount, &l.SubscriberCount)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &l, nil
+}
+
+// GetByOwner returns the lists owned by ownerID. Private lists are only
+// included when includePrivate is set.
+func (r *Repository) GetByOwner(ownerID int, includePrivate bool) ([]list.List, error) {
+	rows, err := r.DB.Query(selectList+`
+		WHERE l.owner_id = ? AND (? OR NOT l.private)
+		ORDER BY l.created_at DESC, l.id DESC
+	`, ownerID, includePrivate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lists := []list.List{}
+	for rows.Next() {
+		var l list.List
+		err := rows.Scan(&l.Id, &l.OwnerID, &l.Name, &l.Description, &l.Private, &l.CreatedAt, &l.MemberCount, &l.SubscriberCount)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, l)
+	}
+	return lists, rows.Err()
+}
+
+func (r *Repository) Update(id int, input list.ListInput) error {
+	query := `UPDATE lists SET name = ?, description = ?, private = ? WHERE id = ?`
+	_, err := r.DB.Exec(query, input.Name, input.Description, input.Private, id)
+	if err != nil {
+		return err
+	}
+
+	// Only the owner may follow a private list.
+	if input.Private {
+		_, err = r.DB.Exec(`
+			DELETE s FROM list_subscribers s JOIN lists l ON l.id = s.list_id
+			WHERE s.list_id = ? AND s.user_id <> l.owner_id
+		`, id)
+	}
+	return err
+}
+
+func (r *Repository) Delete(id int) error {
+	query := `DELETE FROM lists WHERE id = ?`
+	_, err := r.DB.Exec(query, id)
+	return err
+}
+
+func (r *Repository) AddMember(listID, userID int) error {
+	query := `INSERT IGNORE INTO list_members (list_id, user_id) VALUES (?, ?)`
+	_, err := r.DB.Exec(query, listID, userID)
+	return err
+}
+
+func (r *Repository) RemoveMember(listID, userID int) error {
+	query := `DELETE FROM list_members WHERE list_id = ? AND user_id = ?`
+	_, err := r.DB.Exec(query, listID, userID)
+	return err
+}
+
+func (r *Repository) GetMembers(listID, limit, offset int) ([]user.UserSummary, error) {
+	query := `
+		SELECT u.id, u.name, u.followers_count
+		FROM list_members m JOIN users u ON u.id = m.user_id
+		WHERE m.list_id = ?
+		ORDER BY m.created_at DESC, u.id DESC
+		LIMIT ? OFFSET ?
+	`
+	return r.summaries(query, listID, limit, offset)
+}
+
+func (r *Repository) Subscribe(listID, userID int) error {
+	query := `INSERT IGNORE INTO list_subscribers (list_id, user_id) VALUES (?, ?)`
+	_, err := r.DB.Exec(query, listID, userID)
+	return err
+}
+
+func (r *Repository) Unsubscribe(listID, userID int) error {
+	query := `DELETE FROM list_subscribers WHERE list_id = ? AND user_id = ?`
+	_, err := r.DB.Exec(query, listID, userID)
+	return err
+}
+
+func (r *Repository) GetSubscribers(listID, limit, offset int) ([]user.UserSummary, error) {
+	query := `
+		SELECT u.id, u.name, u.followers_count
+		FROM list_subscribers s JOIN users u ON u.id = s.user_id
+		WHERE s.list_id = ?
+		ORDER BY s.created_at DESC, u.id DESC
+		LIMIT ? OFFSET ?
+	`
+	return r.summaries(query, listID, limit, offset)
+}
+
+func (r *Repository) summaries(query string, args ...interface{}) ([]user.UserSummary, error) {
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []user.UserSummary{}
+	for rows.Next() {
+		var u user.UserSummary
+		if err := rows.Scan(&u.Id, &u.Name, &u.FollowersCount); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
@@ -0,0 +1,138 @@
+package mediaRepo
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"ualabackend/entities/media"
+)
+
+const mediaColumns = `id, owner_id, tweet_id, kind, mime_type, size, width, height, alt_text, blob_key, thumbnail_key, created_at`
+
+type Repository struct {
+	DB *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// Create records an uploaded blob. The media stays detached until a tweet
+// references it.
+func (r *Repository) Create(m media.Media) (*media.Media, error) {
+	result, err := r.DB.Exec(`
+		INSERT INTO media (owner_id, kind, mime_type, size, width, height, alt_text, blob_key, thumbnail_key, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, m.OwnerID, m.Kind, m.MimeType, m.Size, m.Width, m.Height, m.AltText, m.BlobKey, m.ThumbnailKey, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(int(id))
+}
+
+func (r *Repository) GetByID(id int) (*media.Media, error) {
+	row := r.DB.QueryRow(`SELECT `+mediaColumns+` FROM media WHERE id = ?`, id)
+	m, err := scanMedia(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// ForTweets returns the media attached to each of the given tweets, in the
+// order they were attached.
+func (r *Repository) ForTweets(tweetIDs []int) (map[int][]media.Media, error) {
+	result := map[int][]media.Media{}
+	if len(tweetIDs) == 0 {
+		return result, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(tweetIDs)), ",")
+	args := make([]interface{}, len(tweetIDs))
+	for i, id := range tweetIDs {
+		args[i] = id
+	}
+	rows, err := r.DB.Query(`
+		SELECT `+mediaColumns+` FROM media WHERE tweet_id IN (`+placeholders+`) ORDER BY tweet_id, position
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		m, err := scanMedia(rows)
+		if err != nil {
+			return nil, err
+		}
+		result[*m.TweetID] = append(result[*m.TweetID], m)
+	}
+	return result, rows.Err()
+}
+
+func (r *Repository) SetAltText(id int, altText string) error {
+	_, err := r.DB.Exec(`UPDATE media SET alt_text = ? WHERE id = ?`, altText, id)
+	return err
+}
+
+// Orphaned returns media whose owner was deleted, and media not attached to
+// any tweet that was uploaded before cutoff (never used, or whose tweet was
+// purged).
+func (r *Repository) Orphaned(cutoff time.Time) ([]media.Media, error) {
+	rows, err := r.DB.Query(`
+		SELECT `+mediaColumns+` FROM media
+		WHERE owner_id IS NULL OR (tweet_id IS NULL AND created_at < ?)
+	`, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []media.Media{}
+	for rows.Next() {
+		m, err := scanMedia(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, m)
+	}
+	return items, rows.Err()
+}
+
+func (r *Repository) Delete(id int) error {
+	_, err := r.DB.Exec(`DELETE FROM media WHERE id = ?`, id)
+	return err
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanMedia(s scanner) (media.Media, error) {
+	var m media.Media
+	var ownerID, tweetID sql.NullInt64
+	err := s.Scan(&m.Id, &ownerID, &tweetID, &m.Kind, &m.MimeType, &m.Size, &m.Width, &m.Height,
+		&m.AltText, &m.BlobKey, &m.ThumbnailKey, &m.CreatedAt)
+	if err != nil {
+		return m, err
+	}
+	m.OwnerID = int(ownerID.Int64)
+	if tweetID.Valid {
+		id := int(tweetID.Int64)
+		m.TweetID = &id
+	}
+	m.URL = fmt.Sprintf("/media/%d/file", m.Id)
+	if m.ThumbnailKey != "" {
+		m.ThumbnailURL = fmt.Sprintf("/media/%d/thumbnail", m.Id)
+	}
+	return m, nil
+}
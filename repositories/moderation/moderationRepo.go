@@ -0,0 +1,311 @@
+package moderationRepo
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+	"ualabackend/entities/moderation"
+)
+
+var (
+	// ErrNotFound is returned when the report does not exist.
+	ErrNotFound = errors.New("report not found")
+	// ErrTargetNotFound is returned when the reported or acted on tweet or
+	// user does not exist.
+	ErrTargetNotFound = errors.New("target not found")
+	// ErrDuplicateReport is returned when the reporter already has an open
+	// report on the same target.
+	ErrDuplicateReport = errors.New("duplicate report")
+	// ErrAlreadyDecided is returned when deciding on a closed report.
+	ErrAlreadyDecided = errors.New("report already decided")
+	// ErrActionMismatch is returned when an action does not apply to the
+	// type of its target.
+	ErrActionMismatch = errors.New("action does not apply to target")
+)
+
+const reportColumns = `id, reporter_id, target_type, target_id, reason, details, status, created_at, resolved_at`
+
+const actionColumns = `id, moderator_id, report_id, action, target_type, target_id, note, created_at`
+
+type Repository struct {
+	DB *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// CreateReport files an open report. The target must have been validated by
+// the caller.
+func (r *Repository) CreateReport(reporterID int, input moderation.ReportInput) (*moderation.Report, error) {
+	var exists bool
+	err := r.DB.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM reports
+			WHERE reporter_id = ? AND target_type = ? AND target_id = ? AND status = ?)
+	`, reporterID, input.TargetType, input.TargetID, moderation.StatusOpen).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrDuplicateReport
+	}
+
+	result, err := r.DB.Exec(`
+		INSERT INTO reports (reporter_id, target_type, target_id, reason, details, status, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, reporterID, input.TargetType, input.TargetID, input.Reason, input.Details, moderation.StatusOpen, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return r.GetReport(int(id))
+}
+
+// GetReport returns the report, or nil if it does not exist.
+func (r *Repository) GetReport(id int) (*moderation.Report, error) {
+	row := r.DB.QueryRow(`SELECT `+reportColumns+` FROM reports WHERE id = ?`, id)
+	report, err := scanReport(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// Queue returns the reports with the given status, oldest first, so the
+// review queue is worked in the order reports arrived.
+func (r *Repository) Queue(status string, limit, offset int) ([]moderation.Report, error) {
+	rows, err := r.DB.Query(`
+		SELECT `+reportColumns+` FROM reports
+		WHERE status = ?
+		ORDER BY created_at ASC, id ASC
+		LIMIT ? OFFSET ?
+	`, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := []moderation.Report{}
+	for rows.Next() {
+		report, err := scanReport(rows)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, rows.Err()
+}
+
+// Decide closes an open report. ActionDismiss closes only this report as
+// dismissed; any other action is applied to the report's target and
+// resolves every open report on that target. The decision is recorded in
+// the audit trail in the same transaction.
+func (r *Repository) Decide(reportID, moderatorID int, input moderation.DecisionInput) (*moderation.Report, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	report, err := scanReport(tx.QueryRow(`SELECT `+reportColumns+` FROM reports WHERE id = ? FOR UPDATE`, reportID))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if report.Status != moderation.StatusOpen {
+		return nil, ErrAlreadyDecided
+	}
+
+	now := time.Now()
+	if input.Action == moderation.ActionDismiss {
+		_, err = tx.Exec(`UPDATE reports SET status = ?, resolved_at = ? WHERE id = ?`,
+			moderation.StatusDismissed, now, reportID)
+	} else {
+		if moderation.TargetOf(input.Action) != report.TargetType {
+			return nil, ErrActionMismatch
+		}
+		if err := apply(tx, input.Action, report.TargetID, now); err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(`
+			UPDATE reports SET status = ?, resolved_at = ?
+			WHERE target_type = ? AND target_id = ? AND status = ?
+		`, moderation.StatusResolved, now, report.TargetType, report.TargetID, moderation.StatusOpen)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = record(tx, moderation.Action{
+		ModeratorID: moderatorID,
+		ReportID:    &reportID,
+		Action:      input.Action,
+		TargetType:  report.TargetType,
+		TargetID:    report.TargetID,
+		Note:        input.Note,
+	}, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetReport(reportID)
+}
+
+// Act applies or reverts an enforcement action outside of any report and
+// records it in the audit trail.
+func (r *Repository) Act(moderatorID int, input moderation.ActionInput) error {
+	target := moderation.TargetOf(input.Action)
+	if target == "" || target != input.TargetType {
+		return ErrActionMismatch
+	}
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if err := apply(tx, input.Action, input.TargetID, now); err != nil {
+		return err
+	}
+	err = record(tx, moderation.Action{
+		ModeratorID: moderatorID,
+		Action:      input.Action,
+		TargetType:  input.TargetType,
+		TargetID:    input.TargetID,
+		Note:        input.Note,
+	}, now)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Enforced reports whether the state action puts in place already holds
+// for targetID: the tweet is hidden, the user suspended or their reach
+// restricted. The reverting action reports the same state.
+func (r *Repository) Enforced(action string, targetID int) (bool, error) {
+	var query string
+	switch action {
+	case moderation.ActionHideTweet, moderation.ActionUnhideTweet:
+		query = `SELECT hidden_at IS NOT NULL FROM tweets WHERE id = ?`
+	case moderation.ActionSuspendUser, moderation.ActionUnsuspendUser:
+		query = `SELECT suspended_at IS NOT NULL FROM users WHERE id = ?`
+	case moderation.ActionRestrictReach, moderation.ActionUnrestrictReach:
+		query = `SELECT reach_restricted FROM users WHERE id = ?`
+	default:
+		return false, ErrActionMismatch
+	}
+
+	var enforced bool
+	err := r.DB.QueryRow(query, targetID).Scan(&enforced)
+	if err == sql.ErrNoRows {
+		return false, ErrTargetNotFound
+	}
+	return enforced, err
+}
+
+// Actions returns the audit trail, newest first. An empty targetType returns
+// the decisions on every target.
+func (r *Repository) Actions(targetType string, targetID, limit, offset int) ([]moderation.Action, error) {
+	query := `SELECT ` + actionColumns + ` FROM moderation_actions`
+	args := []interface{}{}
+	if targetType != "" {
+		query += ` WHERE target_type = ? AND target_id = ?`
+		args = append(args, targetType, targetID)
+	}
+	query += ` ORDER BY id DESC LIMIT ? OFFSET ?`
+	args = append(args, limit, offset)
+
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	actions := []moderation.Action{}
+	for rows.Next() {
+		var a moderation.Action
+		var reportID sql.NullInt64
+		err := rows.Scan(&a.Id, &a.ModeratorID, &reportID, &a.Action, &a.TargetType, &a.TargetID, &a.Note, &a.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if reportID.Valid {
+			id := int(reportID.Int64)
+			a.ReportID = &id
+		}
+		actions = append(actions, a)
+	}
+	return actions, rows.Err()
+}
+
+// apply enforces action on the tweet or user targetID.
+func apply(tx *sql.Tx, action string, targetID int, now time.Time) error {
+	table := "users"
+	if moderation.TargetOf(action) == moderation.TargetTweet {
+		table = "tweets"
+	}
+	var exists bool
+	if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM `+table+` WHERE id = ?)`, targetID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrTargetNotFound
+	}
+
+	var err error
+	switch action {
+	case moderation.ActionHideTweet:
+		_, err = tx.Exec(`UPDATE tweets SET hidden_at = ? WHERE id = ? AND hidden_at IS NULL`, now, targetID)
+	case moderation.ActionUnhideTweet:
+		_, err = tx.Exec(`UPDATE tweets SET hidden_at = NULL WHERE id = ?`, targetID)
+	case moderation.ActionSuspendUser:
+		_, err = tx.Exec(`UPDATE users SET suspended_at = ? WHERE id = ? AND suspended_at IS NULL`, now, targetID)
+	case moderation.ActionUnsuspendUser:
+		_, err = tx.Exec(`UPDATE users SET suspended_at = NULL WHERE id = ?`, targetID)
+	case moderation.ActionRestrictReach:
+		_, err = tx.Exec(`UPDATE users SET reach_restricted = TRUE WHERE id = ?`, targetID)
+	case moderation.ActionUnrestrictReach:
+		_, err = tx.Exec(`UPDATE users SET reach_restricted = FALSE WHERE id = ?`, targetID)
+	default:
+		return ErrActionMismatch
+	}
+	return err
+}
+
+func record(tx *sql.Tx, a moderation.Action, now time.Time) error {
+	_, err := tx.Exec(`
+		INSERT INTO moderation_actions (moderator_id, report_id, action, target_type, target_id, note, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, a.ModeratorID, a.ReportID, a.Action, a.TargetType, a.TargetID, a.Note, now)
+	return err
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanReport(s scanner) (moderation.Report, error) {
+	var report moderation.Report
+	var reporterID sql.NullInt64
+	var resolvedAt sql.NullTime
+	err := s.Scan(&report.Id, &reporterID, &report.TargetType, &report.TargetID, &report.Reason,
+		&report.Details, &report.Status, &report.CreatedAt, &resolvedAt)
+	report.ReporterID = int(reporterID.Int64)
+	if resolvedAt.Valid {
+		report.ResolvedAt = &resolvedAt.Time
+	}
+	return report, err
+}
@@ -0,0 +1,138 @@
+package pollRepo
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+	"ualabackend/entities/poll"
+)
+
+var (
+	// ErrNoPoll is returned when the tweet carries no poll.
+	ErrNoPoll = errors.New("tweet has no poll")
+	// ErrClosed is returned when voting on a poll past its closing time.
+	ErrClosed = errors.New("poll closed")
+	// ErrAlreadyVoted is returned on a second vote by the same user.
+	ErrAlreadyVoted = errors.New("already voted")
+	// ErrInvalidOption is returned when the option does not exist.
+	ErrInvalidOption = errors.New("invalid option")
+)
+
+type Repository struct {
+	DB *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// Get returns the poll of tweetID as seen by viewerID, or nil when the tweet
+// has none. A poll is closed as soon as its closing time passes; the tallies
+// are only included when the results visibility allows it.
+func (r *Repository) Get(tweetID, viewerID int) (*poll.Poll, error) {
+	var p poll.Poll
+	err := r.DB.QueryRow(`
+		SELECT closes_at, results_visibility FROM polls WHERE tweet_id = ?
+	`, tweetID).Scan(&p.ClosesAt, &p.ResultsVisibility)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.Closed = !time.Now().Before(p.ClosesAt)
+
+	var vote int
+	err = r.DB.QueryRow(`
+		SELECT position FROM poll_votes WHERE tweet_id = ? AND user_id = ?
+	`, tweetID, viewerID).Scan(&vote)
+	switch {
+	case err == nil:
+		p.ViewerVote = &vote
+	case err != sql.ErrNoRows:
+		return nil, err
+	}
+
+	rows, err := r.DB.Query(`
+		SELECT position, label, votes FROM poll_options WHERE tweet_id = ? ORDER BY position
+	`, tweetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	showResults := p.Closed || (p.ResultsVisibility == poll.ResultsAfterVote && p.ViewerVote != nil)
+	total := 0
+	p.Options = []poll.Option{}
+	for rows.Next() {
+		var o poll.Option
+		var votes int
+		if err := rows.Scan(&o.Position, &o.Label, &votes); err != nil {
+			return nil, err
+		}
+		if showResults {
+			o.Votes = &votes
+			total += votes
+		}
+		p.Options = append(p.Options, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if showResults {
+		p.TotalVotes = &total
+	}
+	return &p, nil
+}
+
+// Vote records userID's vote for the option at position and updates its
+// tally in the same transaction.
+func (r *Repository) Vote(tweetID, userID, position int) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var closesAt time.Time
+	err = tx.QueryRow(`SELECT closes_at FROM polls WHERE tweet_id = ?`, tweetID).Scan(&closesAt)
+	if err == sql.ErrNoRows {
+		return ErrNoPoll
+	}
+	if err != nil {
+		return err
+	}
+	if !time.Now().Before(closesAt) {
+		return ErrClosed
+	}
+
+	result, err := tx.Exec(`
+		UPDATE poll_options SET votes = votes + 1 WHERE tweet_id = ? AND position = ?
+	`, tweetID, position)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrInvalidOption
+	}
+
+	result, err = tx.Exec(`
+		INSERT IGNORE INTO poll_votes (tweet_id, user_id, position, created_at) VALUES (?, ?, ?, ?)
+	`, tweetID, userID, position, time.Now())
+	if err != nil {
+		return err
+	}
+	affected, err = result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrAlreadyVoted
+	}
+
+	return tx.Commit()
+}
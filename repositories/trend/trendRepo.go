@@ -0,0 +1,89 @@
+package trendRepo
+
+import (
+	"database/sql"
+	"time"
+	"ualabackend/trends"
+)
+
+// Repository stores the trends checkpoint: the sketch of every time bucket,
+// the candidate terms and when the checkpoint was taken.
+type Repository struct {
+	DB *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// SaveCheckpoint replaces the stored checkpoint with cp.
+func (r *Repository) SaveCheckpoint(cp trends.Checkpoint) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"trend_buckets", "trend_candidates", "trend_checkpoint"} {
+		if _, err := tx.Exec(`DELETE FROM ` + table); err != nil {
+			return err
+		}
+	}
+	for _, b := range cp.Buckets {
+		if _, err := tx.Exec(`INSERT INTO trend_buckets (start, data) VALUES (?, ?)`, b.Start, b.Data); err != nil {
+			return err
+		}
+	}
+	for term, seen := range cp.Candidates {
+		if _, err := tx.Exec(`INSERT INTO trend_candidates (term, last_seen) VALUES (?, ?)`, term, seen); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`INSERT INTO trend_checkpoint (id, taken_at) VALUES (1, ?)`, cp.At); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// LoadCheckpoint returns the stored checkpoint, or nil if none was saved yet.
+func (r *Repository) LoadCheckpoint() (*trends.Checkpoint, error) {
+	cp := trends.Checkpoint{Candidates: map[string]time.Time{}}
+	err := r.DB.QueryRow(`SELECT taken_at FROM trend_checkpoint WHERE id = 1`).Scan(&cp.At)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.DB.Query(`SELECT start, data FROM trend_buckets`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var b trends.BucketData
+		if err := rows.Scan(&b.Start, &b.Data); err != nil {
+			return nil, err
+		}
+		cp.Buckets = append(cp.Buckets, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = r.DB.Query(`SELECT term, last_seen FROM trend_candidates`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var term string
+		var seen time.Time
+		if err := rows.Scan(&term, &seen); err != nil {
+			return nil, err
+		}
+		cp.Candidates[term] = seen
+	}
+	return &cp, rows.Err()
+}
@@ -1,112 +1,1292 @@
 package tweetRepo
 
 import (
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
+	"ualabackend/entities/moderation"
+	"ualabackend/entities/poll"
 	"ualabackend/entities/tweet"
+	"ualabackend/filter"
+	"ualabackend/links"
+	"ualabackend/ranking"
+	"ualabackend/search"
+	"unicode/utf8"
 )
 
+// visibleTo restricts a query on tweets aliased t to the non-deleted ones the
+// viewer bound to both placeholders may read: tweets hidden by a moderator
+// and those of deactivated or suspended authors are hidden, and protected
+// authors are only visible to themselves and their approved followers.
+const visibleTo = `
+	t.deleted_at IS NULL AND t.hidden_at IS NULL
+	AND (SELECT COALESCE(a.deactivated_at, a.suspended_at) FROM users a WHERE a.id = t.author_id) IS NULL
+	AND (t.author_id = ?
+		OR NOT (SELECT a.protected FROM users a WHERE a.id = t.author_id)
+		OR EXISTS (SELECT 1 FROM follows v WHERE v.follower_id = ? AND v.followed_id = t.author_id))
+`
+
+// reachableBy further restricts visibleTo on surfaces beyond the author's
+// followers: authors whose reach was restricted by a moderator only reach
+// themselves and their followers. The viewer is bound to both placeholders.
+const reachableBy = `
+	(t.author_id = ?
+		OR NOT (SELECT a.reach_restricted FROM users a WHERE a.id = t.author_id)
+		OR EXISTS (SELECT 1 FROM follows v WHERE v.follower_id = ? AND v.followed_id = t.author_id))
+`
+
+// unblocked hides the tweets of authors who blocked, or were blocked by, the
+// viewer bound to both placeholders.
+const unblocked = `
+	NOT EXISTS (SELECT 1 FROM blocks b
+		WHERE (b.blocker_id = ? AND b.blocked_id = t.author_id)
+			OR (b.blocker_id = t.author_id AND b.blocked_id = ?))
+`
+
+const tweetColumns = `t.id, t.author_id, t.message, t.timestamp, t.edited_at, t.edit_count, t.labels`
+
+var (
+	// ErrNotFound is returned when the tweet does not exist.
+	ErrNotFound = errors.New("tweet not found")
+	// ErrEditWindowClosed is returned when editing after the edit window.
+	ErrEditWindowClosed = errors.New("edit window closed")
+	// ErrTooManyEdits is returned when the tweet reached the maximum edits.
+	ErrTooManyEdits = errors.New("maximum number of edits reached")
+	// ErrRestoreWindowClosed is returned when restoring a tweet deleted too long ago.
+	ErrRestoreWindowClosed = errors.New("restore window closed")
+	// ErrInvalidMedia is returned when attaching media the author cannot use.
+	ErrInvalidMedia = errors.New("invalid media")
+	// ErrRejected is returned when the content filter rejects a message.
+	ErrRejected = errors.New("rejected by the content filter")
+)
+
+// MaxMedia is how many media a tweet may reference.
+const MaxMedia = 4
+
+// EditPolicy limits how long after creation and how many times a tweet may
+// be edited.
+type EditPolicy struct {
+	Window   time.Duration
+	MaxEdits int
+}
+
+var DefaultEditPolicy = EditPolicy{Window: 30 * time.Minute, MaxEdits: 5}
+
+// DefaultLinkBase is the public address short links are built on when none
+// is configured.
+const DefaultLinkBase = "http://localhost:9090"
+
 type Repository struct {
-	DB *sql.DB
+	DB    *sql.DB
+	Index search.Index
+	Edits EditPolicy
+	// LinkBase is the public address of this backend, used to build the
+	// short links that replace URLs in messages.
+	LinkBase string
+	// Trends, when set, is told about every newly published tweet.
+	Trends Recorder
+	// Filter, when set, screens every message before it is stored.
+	Filter *filter.Pipeline
 }
 
-func NewRepository(db *sql.DB) *Repository {
-	return &Repository{DB: db}
+// Recorder receives the text of tweets as they are published.
+type Recorder interface {
+	Record(text string, at time.Time)
+}
+
+func NewRepository(db *sql.DB, index search.Index) *Repository {
+	return &Repository{DB: db, Index: index, Edits: DefaultEditPolicy, LinkBase: DefaultLinkBase}
 }
 
 func (r *Repository) Create(authorID int, message string) error {
+	_, _, err := r.CreateWithAttachments(authorID, message, nil, nil)
+	return err
+}
+
+// CreateWithAttachments creates a tweet carrying an optional poll and up to
+// MaxMedia media uploaded by the author. The poll must have been validated
+// by the caller. It returns ErrInvalidMedia when a media ID does not belong
+// to the author or is already attached, and ErrRejected when the content
+// filter refuses the message. Along with the ID of the new tweet, it returns
+// the filter's decision, which tells whether the tweet was held for review
+// or labeled.
+func (r *Repository) CreateWithAttachments(authorID int, message string, p *poll.PollInput, mediaIDs []int) (int64, filter.Decision, error) {
+	var decision filter.Decision
+	if len(mediaIDs) > MaxMedia {
+		return 0, decision, ErrInvalidMedia
+	}
 
-	query := `INSERT INTO tweets (author_id, message, timestamp) VALUES (?, ?, ?)`
-	result, err := r.DB.Exec(query, authorID, message, time.Now())
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return 0, decision, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	tweetID, decision, err := r.create(tx, authorID, message, now)
+	if err != nil {
+		return 0, decision, err
+	}
+
+	if p != nil {
+		if err := insertPoll(tx, tweetID, *p, now); err != nil {
+			return 0, decision, err
+		}
+	}
+	for i, mediaID := range mediaIDs {
+		result, err := tx.Exec(`
+			UPDATE media SET tweet_id = ?, position = ?
+			WHERE id = ? AND owner_id = ? AND tweet_id IS NULL
+		`, tweetID, i+1, mediaID, authorID)
+		if err != nil {
+			return 0, decision, err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return 0, decision, err
+		}
+		if affected == 0 {
+			return 0, decision, ErrInvalidMedia
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, decision, err
+	}
+	r.remember(tweetID, authorID, message, now)
+	return tweetID, decision, r.indexPublished(int(tweetID))
+}
+
+func insertPoll(tx *sql.Tx, tweetID int64, input poll.PollInput, now time.Time) error {
+	closesAt := now.Add(time.Duration(input.DurationMinutes) * time.Minute)
+	_, err := tx.Exec(`
+		INSERT INTO polls (tweet_id, closes_at, results_visibility) VALUES (?, ?, ?)
+	`, tweetID, closesAt, input.ResultsVisibility)
 	if err != nil {
 		return err
 	}
+	for i, label := range input.Options {
+		_, err := tx.Exec(`
+			INSERT INTO poll_options (tweet_id, position, label) VALUES (?, ?, ?)
+		`, tweetID, i+1, label)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// create screens the message with the content filter, then inserts the
+// tweet with its first revision, bumps the author's counter and fans it out
+// to the followers' feeds. URLs in the message are replaced by short links.
+// A held tweet is stored hidden and queued for review.
+func (r *Repository) create(tx *sql.Tx, authorID int, message string, at time.Time) (int64, filter.Decision, error) {
+	decision, err := r.screen(authorID, 0, message, at)
+	if err != nil {
+		return 0, decision, err
+	}
+
+	message, shortLinks, err := r.shorten(tx, message)
+	if err != nil {
+		return 0, decision, err
+	}
+
+	query := `INSERT INTO tweets (author_id, message, timestamp, labels) VALUES (?, ?, ?, ?)`
+	result, err := tx.Exec(query, authorID, message, at, labelsJSON(decision.Labels))
+	if err != nil {
+		return 0, decision, err
+	}
 
 	tweetID, err := result.LastInsertId()
 	if err != nil {
+		return 0, decision, err
+	}
+	if err := hold(tx, tweetID, decision, at); err != nil {
+		return 0, decision, err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO tweet_revisions (tweet_id, revision, message, created_at) VALUES (?, 1, ?, ?)
+	`, tweetID, message, at)
+	if err != nil {
+		return 0, decision, err
+	}
+
+	_, err = tx.Exec(`UPDATE users SET tweet_count = tweet_count + 1 WHERE id = ?`, authorID)
+	if err != nil {
+		return 0, decision, err
+	}
+
+	if err := saveLinks(tx, tweetID, shortLinks); err != nil {
+		return 0, decision, err
+	}
+
+	if err := fanOut(tx, authorID, tweetID); err != nil {
+		return 0, decision, err
+	}
+	return tweetID, decision, nil
+}
+
+// screen runs the content filter on a message, returning ErrRejected when
+// it must not be stored. tweetID is the tweet being edited, or 0 for a new
+// one.
+func (r *Repository) screen(authorID, tweetID int, message string, at time.Time) (filter.Decision, error) {
+	if r.Filter == nil {
+		return filter.Decision{}, nil
+	}
+	decision := r.Filter.Check(filter.Input{AuthorID: authorID, TweetID: tweetID, Text: message, At: at})
+	if decision.Action == filter.ActionReject {
+		return decision, ErrRejected
+	}
+	return decision, nil
+}
+
+// remember tells the content filter a screened message was committed as
+// tweetID, so rules such as duplicates count it from now on.
+func (r *Repository) remember(tweetID int64, authorID int, message string, at time.Time) {
+	if r.Filter == nil {
+		return
+	}
+	r.Filter.Record(filter.Input{AuthorID: authorID, TweetID: int(tweetID), Text: message, At: at})
+}
+
+// hold hides a tweet the content filter held and files a report so it shows
+// up in the moderators' review queue. Approving it means unhiding it.
+func hold(tx *sql.Tx, tweetID int64, decision filter.Decision, at time.Time) error {
+	if decision.Action != filter.ActionHold {
+		return nil
+	}
+	if _, err := tx.Exec(`UPDATE tweets SET hidden_at = ? WHERE id = ?`, at, tweetID); err != nil {
 		return err
 	}
+	_, err := tx.Exec(`
+		INSERT INTO reports (reporter_id, target_type, target_id, reason, details, status, created_at)
+		VALUES (NULL, ?, ?, ?, ?, ?, ?)
+	`, moderation.TargetTweet, tweetID, moderation.ReasonContentFilter,
+		"Reglas: "+strings.Join(decision.Rules, ", "), moderation.StatusOpen, at)
+	return err
+}
 
+// labelsJSON encodes the labels of a tweet for the labels column, NULL when
+// there are none.
+func labelsJSON(labels []string) interface{} {
+	if len(labels) == 0 {
+		return nil
+	}
+	data, _ := json.Marshal(labels)
+	return string(data)
+}
 
-	var followersJSON string
-	err = r.DB.QueryRow("SELECT followers_id FROM users WHERE id = ?", authorID).Scan(&followersJSON)
+// GetAll returns every tweet the viewer may read, newest first, leaving out
+// reach-restricted authors the viewer does not follow and authors on either
+// side of a block with the viewer. A viewerID of 0 means an anonymous reader.
+func (r *Repository) GetAll(viewerID int) ([]tweet.Tweet, error) {
+	query := `SELECT ` + tweetColumns + ` FROM tweets t WHERE ` + unblocked + ` AND ` + visibleTo + ` AND ` + reachableBy + ` ORDER BY t.timestamp DESC`
+	rows, err := r.DB.Query(query, viewerID, viewerID, viewerID, viewerID, viewerID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTweets(rows)
+}
+
+// GetByID returns the tweet if it exists and the viewer may read it, which
+// excludes tweets whose author and the viewer have blocked one another.
+func (r *Repository) GetByID(id, viewerID int) (*tweet.Tweet, error) {
+	query := `SELECT ` + tweetColumns + ` FROM tweets t WHERE t.id = ? AND ` + unblocked + ` AND ` + visibleTo
+	row := r.DB.QueryRow(query, id, viewerID, viewerID, viewerID, viewerID)
+
+	t, err := scanTweet(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+// AuthorOf returns the author of the tweet, deleted or not, or 0 when it
+// does not exist.
+func (r *Repository) AuthorOf(id int) (int, error) {
+	var authorID int
+	err := r.DB.QueryRow(`SELECT author_id FROM tweets WHERE id = ?`, id).Scan(&authorID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return authorID, err
+}
+
+// Message returns the current message of the tweet, whatever its
+// visibility, and whether it is soft-deleted. It returns "" when the tweet
+// does not exist.
+func (r *Repository) Message(id int) (message string, deleted bool, err error) {
+	var deletedAt sql.NullTime
+	err = r.DB.QueryRow(`SELECT message, deleted_at FROM tweets WHERE id = ?`, id).Scan(&message, &deletedAt)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return message, deletedAt.Valid, nil
+}
+
+// Update stores newMessage as a new revision of the tweet. The creation
+// timestamp is kept and edited_at records the edit. The new message goes
+// through the content filter like a new tweet: it returns ErrRejected when
+// refused, and the labels of the decision replace the previous ones.
+func (r *Repository) Update(id int, newMessage string) (filter.Decision, error) {
+	var decision filter.Decision
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return decision, err
+	}
+	defer tx.Rollback()
+
+	var authorID int
+	var message string
+	var createdAt time.Time
+	var editCount int
+	err = tx.QueryRow(`
+		SELECT author_id, message, timestamp, edit_count FROM tweets WHERE id = ? AND deleted_at IS NULL FOR UPDATE
+	`, id).Scan(&authorID, &message, &createdAt, &editCount)
+	if err == sql.ErrNoRows {
+		return decision, ErrNotFound
+	}
+	if err != nil {
+		return decision, err
+	}
+
+	now := time.Now()
+	if now.Sub(createdAt) > r.Edits.Window {
+		return decision, ErrEditWindowClosed
+	}
+	if editCount >= r.Edits.MaxEdits {
+		return decision, ErrTooManyEdits
+	}
+	decision, err = r.screen(authorID, id, newMessage, now)
+	if err != nil {
+		return decision, err
+	}
+	screened := newMessage
+
+	newMessage, shortLinks, err := r.shorten(tx, newMessage)
+	if err != nil {
+		return decision, err
+	}
+	if err := saveLinks(tx, int64(id), shortLinks); err != nil {
+		return decision, err
+	}
+
+	// Tweets created before revisions existed get their original text
+	// recorded as the first revision.
+	_, err = tx.Exec(`
+		INSERT IGNORE INTO tweet_revisions (tweet_id, revision, message, created_at) VALUES (?, 1, ?, ?)
+	`, id, message, createdAt)
+	if err != nil {
+		return decision, err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO tweet_revisions (tweet_id, revision, message, created_at) VALUES (?, ?, ?, ?)
+	`, id, editCount+2, newMessage, now)
+	if err != nil {
+		return decision, err
+	}
+
+	query := `UPDATE tweets SET message = ?, labels = ?, edited_at = ?, edit_count = edit_count + 1 WHERE id = ?`
+	if _, err := tx.Exec(query, newMessage, labelsJSON(decision.Labels), now, id); err != nil {
+		return decision, err
+	}
+	if err := hold(tx, int64(id), decision, now); err != nil {
+		return decision, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return decision, err
+	}
+	r.remember(int64(id), authorID, screened, now)
+	return decision, r.indexTweet(id)
+}
+
+// History returns every version of the tweet, oldest first.
+func (r *Repository) History(id int) ([]tweet.Revision, error) {
+	query := `
+		SELECT tweet_id, revision, message, created_at
+		FROM tweet_revisions WHERE tweet_id = ?
+		ORDER BY revision ASC
+	`
+	rows, err := r.DB.Query(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []tweet.Revision{}
+	for rows.Next() {
+		var rev tweet.Revision
+		if err := rows.Scan(&rev.TweetID, &rev.Revision, &rev.Message, &rev.CreatedAt); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, rev)
+	}
+	return revisions, rows.Err()
+}
+
+// Delete soft-deletes the tweet: it is tombstoned with deleted_at, removed
+// from every feed and from the search index, and can be restored until the
+// purge job removes it for good.
+func (r *Repository) Delete(id int) error {
+	tx, err := r.DB.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
+	var authorID int
+	err = tx.QueryRow(`
+		SELECT author_id FROM tweets WHERE id = ? AND deleted_at IS NULL FOR UPDATE
+	`, id).Scan(&authorID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
 
-	var followers []int
-	err = json.Unmarshal([]byte(followersJSON), &followers)
+	query := `UPDATE tweets SET deleted_at = ? WHERE id = ?`
+	if _, err := tx.Exec(query, time.Now(), id); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE users SET tweet_count = GREATEST(tweet_count - 1, 0) WHERE id = ?`, authorID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE users SET feed = (
+			SELECT COALESCE(JSON_ARRAYAGG(f.tweet_id), JSON_ARRAY())
+			FROM JSON_TABLE(users.feed, '$[*]' COLUMNS (tweet_id BIGINT PATH '$')) f
+			WHERE f.tweet_id <> ?
+		)
+		WHERE JSON_CONTAINS(feed, CAST(? AS JSON))
+	`, id, id)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return r.Index.Remove(id)
+}
+
+// Restore undoes a soft delete made less than window ago, putting the tweet
+// back in its author's followers' feeds.
+func (r *Repository) Restore(id int, window time.Duration) error {
+	tx, err := r.DB.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-for _, followerID := range followers {
-	_, err := r.DB.Exec(`
-		UPDATE users 
-		SET feed = JSON_ARRAY() 
-		WHERE id = ? AND feed IS NULL
-	`, followerID)
+	var authorID int
+	var deletedAt sql.NullTime
+	err = tx.QueryRow(`
+		SELECT author_id, deleted_at FROM tweets WHERE id = ? FOR UPDATE
+	`, id).Scan(&authorID, &deletedAt)
+	if err == sql.ErrNoRows || (err == nil && !deletedAt.Valid) {
+		return ErrNotFound
+	}
 	if err != nil {
 		return err
 	}
+	if time.Since(deletedAt.Time) > window {
+		return ErrRestoreWindowClosed
+	}
 
+	if _, err := tx.Exec(`UPDATE tweets SET deleted_at = NULL WHERE id = ?`, id); err != nil {
+		return err
+	}
 
-	_, err = r.DB.Exec(`
-		UPDATE users 
-		SET feed = JSON_ARRAY_APPEND(feed, '$', ?) 
-		WHERE id = ?
-	`, tweetID, followerID)
+	_, err = tx.Exec(`UPDATE users SET tweet_count = tweet_count + 1 WHERE id = ?`, authorID)
 	if err != nil {
 		return err
 	}
+
+	if err := fanOut(tx, authorID, int64(id)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return r.indexTweet(id)
+}
+
+// PurgeDeleted hard-deletes the tweets soft-deleted more than retention ago
+// and returns how many were removed.
+func (r *Repository) PurgeDeleted(retention time.Duration) (int64, error) {
+	query := `DELETE FROM tweets WHERE deleted_at IS NOT NULL AND deleted_at < ?`
+	result, err := r.DB.Exec(query, time.Now().Add(-retention))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
+// ForceDelete removes the tweet for good right away, without the restore
+// window Delete leaves. It returns ErrNotFound when the tweet does not exist.
+func (r *Repository) ForceDelete(id int) error {
+	if err := r.Delete(id); err != nil {
+		return err
+	}
+	result, err := r.DB.Exec(`DELETE FROM tweets WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
 	return nil
 }
 
-func (r *Repository) GetAll() ([]tweet.Tweet, error) {
-	query := `SELECT id, author_id, message, timestamp FROM tweets ORDER BY timestamp DESC`
-	rows, err := r.DB.Query(query)
+// Timeline returns the tweets in userID's feed as seen by viewerID, newest
+// first. It leaves out authors blocked in either direction by userID or by
+// viewerID, authors muted by userID, and tweets viewerID may not read, such
+// as those of protected authors viewerID does not follow.
+func (r *Repository) Timeline(userID, viewerID, limit, offset int) ([]tweet.Tweet, error) {
+	query := `
+		SELECT ` + tweetColumns + `
+		FROM users u
+		JOIN JSON_TABLE(u.feed, '$[*]' COLUMNS (tweet_id BIGINT PATH '$')) f
+		JOIN tweets t ON t.id = f.tweet_id
+		WHERE u.id = ?
+			AND NOT EXISTS (SELECT 1 FROM blocks b
+				WHERE (b.blocker_id IN (u.id, ?) AND b.blocked_id = t.author_id)
+					OR (b.blocker_id = t.author_id AND b.blocked_id IN (u.id, ?)))
+			AND NOT EXISTS (SELECT 1 FROM mutes m WHERE m.muter_id = u.id AND m.muted_id = t.author_id)
+			AND ` + visibleTo + `
+			AND ` + reachableBy + `
+		ORDER BY t.timestamp DESC, t.id DESC
+		LIMIT ? OFFSET ?
+	`
+	rows, err := r.DB.Query(query, userID, viewerID, viewerID, viewerID, viewerID, viewerID, viewerID, limit, offset)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var tweets []tweet.Tweet
+	return scanTweets(rows)
+}
+
+// ListTimeline merges the tweets of the members of a list, newest first, as
+// seen by viewerID.
+func (r *Repository) ListTimeline(listID, viewerID, limit, offset int) ([]tweet.Tweet, error) {
+	query := `
+		SELECT ` + tweetColumns + `
+		FROM tweets t
+		JOIN list_members m ON m.user_id = t.author_id
+		WHERE m.list_id = ?
+			AND ` + unblocked + `
+			AND ` + visibleTo + `
+			AND ` + reachableBy + `
+		ORDER BY t.timestamp DESC, t.id DESC
+		LIMIT ? OFFSET ?
+	`
+	rows, err := r.DB.Query(query, listID, viewerID, viewerID, viewerID, viewerID, viewerID, viewerID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTweets(rows)
+}
+
+// RankingCandidates returns up to limit tweets, newest first, that may
+// appear in userID's ranked timeline: those written between since and until
+// by the accounts userID follows and by the accounts they follow in
+// turn, with the same exclusions as Timeline. Engagement counts poll votes and
+// link clicks on the tweet; affinity counts userID's poll votes on the
+// author's tweets and the direct messages userID sent to conversations the
+// author is in.
+func (r *Repository) RankingCandidates(userID int, since, until time.Time, limit int) ([]ranking.Candidate, error) {
+	query := `
+		SELECT ` + tweetColumns + `,
+			CASE WHEN EXISTS (SELECT 1 FROM follows f WHERE f.follower_id = ? AND f.followed_id = t.author_id)
+				THEN ` + strconv.Itoa(ranking.DegreeFollowed) + ` ELSE ` + strconv.Itoa(ranking.DegreeNetwork) + ` END,
+			(SELECT COUNT(*) FROM poll_votes pv WHERE pv.tweet_id = t.id)
+				+ (SELECT COALESCE(SUM(l.clicks), 0) FROM tweet_links tl JOIN links l ON l.code = tl.code WHERE tl.tweet_id = t.id),
+			(SELECT COUNT(*) FROM poll_votes pv JOIN tweets pt ON pt.id = pv.tweet_id
+				WHERE pv.user_id = ? AND pt.author_id = t.author_id)
+				+ (SELECT COUNT(*) FROM messages m
+					JOIN conversation_participants cp ON cp.conversation_id = m.conversation_id
+					WHERE m.sender_id = ? AND cp.user_id = t.author_id)
+		FROM tweets t
+		WHERE t.timestamp >= ? AND t.timestamp <= ?
+			AND t.author_id <> ?
+			AND (EXISTS (SELECT 1 FROM follows f WHERE f.follower_id = ? AND f.followed_id = t.author_id)
+				OR EXISTS (SELECT 1 FROM follows f1 JOIN follows f2 ON f2.follower_id = f1.followed_id
+					WHERE f1.follower_id = ? AND f2.followed_id = t.author_id))
+			AND NOT EXISTS (SELECT 1 FROM blocks b
+				WHERE (b.blocker_id = ? AND b.blocked_id = t.author_id)
+					OR (b.blocker_id = t.author_id AND b.blocked_id = ?))
+			AND NOT EXISTS (SELECT 1 FROM mutes m WHERE m.muter_id = ? AND m.muted_id = t.author_id)
+			AND ` + visibleTo + `
+			AND ` + reachableBy + `
+		ORDER BY t.timestamp DESC, t.id DESC
+		LIMIT ?
+	`
+	rows, err := r.DB.Query(query, userID, userID, userID, since, until, userID, userID, userID,
+		userID, userID, userID, userID, userID, userID, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	candidates := []ranking.Candidate{}
 	for rows.Next() {
-		var t tweet.Tweet
-		if err := rows.Scan(&t.Id, &t.Author_id, &t.Message, &t.Timestamp); err != nil {
+		var c ranking.Candidate
+		t, err := scanTweet(rows, &c.Degree, &c.Engagement, &c.Affinity)
+		if err != nil {
 			return nil, err
 		}
-		tweets = append(tweets, t)
+		c.Tweet = t
+		candidates = append(candidates, c)
+	}
+	return candidates, rows.Err()
+}
+
+// ByAuthor returns every non-deleted tweet written by authorID, newest first,
+// regardless of visibility. It backs the author's own data export.
+func (r *Repository) ByAuthor(authorID int) ([]tweet.Tweet, error) {
+	query := `SELECT ` + tweetColumns + ` FROM tweets t WHERE t.author_id = ? AND t.deleted_at IS NULL ORDER BY t.timestamp DESC`
+	rows, err := r.DB.Query(query, authorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTweets(rows)
+}
+
+// searchBatch is how many hits Search reads from the index at a time.
+const searchBatch = 100
+
+// Search runs q on the search index and returns the requested page of the
+// matching tweets the viewer may find, in the order of the index. Hits are
+// read in batches and filtered in SQL, so hits the viewer may not find do
+// not leave the page short.
+func (r *Repository) Search(q search.Query, viewerID int) ([]tweet.Tweet, error) {
+	offset, limit := q.Offset, q.Limit
+	q.Limit = max(limit, searchBatch)
+
+	tweets := []tweet.Tweet{}
+	for q.Offset = 0; ; q.Offset += q.Limit {
+		hits, err := r.Index.Search(q)
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]int, len(hits))
+		for i, h := range hits {
+			ids[i] = h.ID
+		}
+
+		found, err := r.GetByIDs(ids, viewerID)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range found {
+			if offset > 0 {
+				offset--
+				continue
+			}
+			tweets = append(tweets, t)
+			if len(tweets) == limit {
+				return tweets, nil
+			}
+		}
+		if len(hits) < q.Limit {
+			return tweets, nil
+		}
+	}
+}
+
+// GetByIDs returns the tweets with the given IDs, preserving the order of ids
+// and skipping the ones that no longer exist, the viewer may not read, or
+// whose author's reach does not extend to the viewer.
+func (r *Repository) GetByIDs(ids []int, viewerID int) ([]tweet.Tweet, error) {
+	tweets := []tweet.Tweet{}
+	if len(ids) == 0 {
+		return tweets, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := make([]interface{}, 0, len(ids)+6)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	args = append(args, viewerID, viewerID, viewerID, viewerID, viewerID, viewerID)
+	query := `SELECT ` + tweetColumns + ` FROM tweets t WHERE t.id IN (` + placeholders + `) AND ` + unblocked + ` AND ` + visibleTo + ` AND ` + reachableBy
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found, err := scanTweets(rows)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int]tweet.Tweet, len(found))
+	for _, t := range found {
+		byID[t.Id] = t
+	}
+	for _, id := range ids {
+		if t, ok := byID[id]; ok {
+			tweets = append(tweets, t)
+		}
 	}
 	return tweets, nil
 }
 
-func (r *Repository) GetByID(id int) (*tweet.Tweet, error) {
-	query := `SELECT id, author_id, message, timestamp FROM tweets WHERE id = ?`
-	row := r.DB.QueryRow(query, id)
+// Documents returns every tweet in its indexable form, used to rebuild the
+// search index at startup.
+func (r *Repository) Documents() ([]search.Document, error) {
+	query := `
+		SELECT t.id, t.author_id, u.name, t.message, t.timestamp
+		FROM tweets t JOIN users u ON u.id = t.author_id
+		WHERE t.deleted_at IS NULL AND t.hidden_at IS NULL
+	`
+	rows, err := r.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var docs []search.Document
+	for rows.Next() {
+		var d search.Document
+		if err := rows.Scan(&d.ID, &d.AuthorID, &d.Author, &d.Text, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		docs = append(docs, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	spans, err := r.linkSpans(`SELECT tl.tweet_id, tl.start_index, tl.end_index, l.url
+		FROM tweet_links tl JOIN links l ON l.code = tl.code
+		ORDER BY tl.tweet_id, tl.position`)
+	if err != nil {
+		return nil, err
+	}
+	for i := range docs {
+		docs[i].Text = expandLinks(docs[i].Text, spans[docs[i].ID])
+	}
+	return docs, nil
+}
+
+// linkSpan is where a short link sits in a stored message and the URL it
+// replaced.
+type linkSpan struct {
+	start, end int
+	url        string
+}
+
+// linkSpans runs a query selecting tweet ID, start, end and URL of short
+// links and groups them by tweet.
+func (r *Repository) linkSpans(query string, args ...interface{}) (map[int][]linkSpan, error) {
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	spans := map[int][]linkSpan{}
+	for rows.Next() {
+		var id int
+		var s linkSpan
+		if err := rows.Scan(&id, &s.start, &s.end, &s.url); err != nil {
+			return nil, err
+		}
+		spans[id] = append(spans[id], s)
+	}
+	return spans, rows.Err()
+}
+
+// expandLinks puts back the original URLs in place of the short links of a
+// stored message, so the search index matches what the author wrote (e.g.
+// "github.com") rather than this backend's address. spans are in order.
+func expandLinks(message string, spans []linkSpan) string {
+	if len(spans) == 0 {
+		return message
+	}
+	runes := []rune(message)
+	var out strings.Builder
+	last := 0
+	for _, s := range spans {
+		if s.start < last || s.end > len(runes) {
+			// The message no longer matches its links; index it as stored.
+			return message
+		}
+		out.WriteString(string(runes[last:s.start]))
+		out.WriteString(s.url)
+		last = s.end
+	}
+	out.WriteString(string(runes[last:]))
+	return out.String()
+}
+
+func (r *Repository) indexTweet(id int) error {
+	_, err := r.index(id)
+	return err
+}
+
+// Reindex brings the search index in line with a tweet changed outside this
+// repository, such as one hidden or restored by a moderator.
+func (r *Repository) Reindex(id int) error {
+	return r.indexTweet(id)
+}
 
+// indexPublished indexes a tweet that was just published and records it in
+// the trends, unless its author's reach is restricted.
+func (r *Repository) indexPublished(id int) error {
+	d, err := r.index(id)
+	if err != nil || d == nil || r.Trends == nil {
+		return err
+	}
+
+	var restricted bool
+	if err := r.DB.QueryRow(`SELECT reach_restricted FROM users WHERE id = ?`, d.AuthorID).Scan(&restricted); err != nil {
+		return err
+	}
+	if !restricted {
+		r.Trends.Record(d.Text, d.CreatedAt)
+	}
+	return nil
+}
+
+// index adds the tweet to the search index, or removes it if it no longer
+// exists, and returns the indexed document. The document carries the
+// original URLs instead of the short links stored in the message.
+func (r *Repository) index(id int) (*search.Document, error) {
+	query := `
+		SELECT t.id, t.author_id, u.name, t.message, t.timestamp
+		FROM tweets t JOIN users u ON u.id = t.author_id
+		WHERE t.id = ? AND t.deleted_at IS NULL AND t.hidden_at IS NULL
+	`
+	var d search.Document
+	err := r.DB.QueryRow(query, id).Scan(&d.ID, &d.AuthorID, &d.Author, &d.Text, &d.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, r.Index.Remove(id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	spans, err := r.linkSpans(`SELECT tl.tweet_id, tl.start_index, tl.end_index, l.url
+		FROM tweet_links tl JOIN links l ON l.code = tl.code
+		WHERE tl.tweet_id = ?
+		ORDER BY tl.position`, id)
+	if err != nil {
+		return nil, err
+	}
+	d.Text = expandLinks(d.Text, spans[id])
+	return &d, r.Index.Add(d)
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTweet reads a row selected with tweetColumns, followed by the extra
+// columns scanned into extra.
+func scanTweet(s scanner, extra ...interface{}) (tweet.Tweet, error) {
 	var t tweet.Tweet
-	if err := row.Scan(&t.Id, &t.Author_id, &t.Message, &t.Timestamp); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
+	var editedAt sql.NullTime
+	var labels sql.NullString
+	dest := append([]interface{}{&t.Id, &t.Author_id, &t.Message, &t.Timestamp, &editedAt, &t.EditCount, &labels}, extra...)
+	if err := s.Scan(dest...); err != nil {
+		return t, err
+	}
+	if editedAt.Valid {
+		t.EditedAt = &editedAt.Time
+	}
+	if labels.Valid {
+		if err := json.Unmarshal([]byte(labels.String), &t.Labels); err != nil {
+			return t, err
+		}
+	}
+	return t, nil
+}
+
+func scanTweets(rows *sql.Rows) ([]tweet.Tweet, error) {
+	tweets := []tweet.Tweet{}
+	for rows.Next() {
+		t, err := scanTweet(rows)
+		if err != nil {
+			return nil, err
+		}
+		tweets = append(tweets, t)
+	}
+	return tweets, rows.Err()
+}
+
+// fanOut appends tweetID to the feed of every follower of authorID.
+func fanOut(tx *sql.Tx, authorID int, tweetID int64) error {
+	var followersJSON string
+	err := tx.QueryRow("SELECT followers_id FROM users WHERE id = ?", authorID).Scan(&followersJSON)
+	if err != nil {
+		return err
+	}
+
+	var followers []int
+	err = json.Unmarshal([]byte(followersJSON), &followers)
+	if err != nil {
+		return err
+	}
+
+	for _, followerID := range followers {
+		_, err := tx.Exec(`
+			UPDATE users 
+			SET feed = JSON_ARRAY() 
+			WHERE id = ? AND feed IS NULL
+		`, followerID)
+		if err != nil {
+			return err
 		}
+
+		_, err = tx.Exec(`
+			UPDATE users 
+			SET feed = JSON_ARRAY_APPEND(feed, '$', ?) 
+			WHERE id = ?
+		`, tweetID, followerID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Schedule stores a tweet to be published by PublishDue at publishAt. Until
+// then it lives only in scheduled_tweets and is invisible to every read path.
+func (r *Repository) Schedule(authorID int, message string, publishAt time.Time) (int64, error) {
+	result, err := r.DB.Exec(`
+		INSERT INTO scheduled_tweets (author_id, message, publish_at, created_at) VALUES (?, ?, ?, ?)
+	`, authorID, message, publishAt, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+const scheduledColumns = `id, author_id, message, publish_at, created_at, status, error`
+
+// scheduledRejection is the error kept on a scheduled tweet the content
+// filter refused to publish.
+const scheduledRejection = "El mensaje infringe las reglas de contenido"
+
+// GetScheduled returns the author's pending and failed tweets, the next to
+// publish first.
+func (r *Repository) GetScheduled(authorID int) ([]tweet.ScheduledTweet, error) {
+	rows, err := r.DB.Query(`
+		SELECT `+scheduledColumns+` FROM scheduled_tweets WHERE author_id = ? ORDER BY publish_at, id
+	`, authorID)
+	if err != nil {
 		return nil, err
 	}
-	return &t, nil
+	defer rows.Close()
+
+	scheduled := []tweet.ScheduledTweet{}
+	for rows.Next() {
+		st, err := scanScheduled(rows)
+		if err != nil {
+			return nil, err
+		}
+		scheduled = append(scheduled, st)
+	}
+	return scheduled, rows.Err()
 }
 
-func (r *Repository) Update(id int, newMessage string) error {
-	query := `UPDATE tweets SET message = ?, timestamp = ? WHERE id = ?`
-	_, err := r.DB.Exec(query, newMessage, time.Now(), id)
+func (r *Repository) GetScheduledByID(id int) (*tweet.ScheduledTweet, error) {
+	row := r.DB.QueryRow(`SELECT `+scheduledColumns+` FROM scheduled_tweets WHERE id = ?`, id)
+	st, err := scanScheduled(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// Reschedule moves a pending tweet to publishAt.
+func (r *Repository) Reschedule(id int, publishAt time.Time) error {
+	_, err := r.DB.Exec(`UPDATE scheduled_tweets SET publish_at = ? WHERE id = ? AND status = ?`, publishAt, id, tweet.ScheduledPending)
 	return err
 }
 
-func (r *Repository) Delete(id int) error {
-	query := `DELETE FROM tweets WHERE id = ?`
-	_, err := r.DB.Exec(query, id)
+// CancelScheduled discards a pending or failed tweet.
+func (r *Repository) CancelScheduled(id int) error {
+	_, err := r.DB.Exec(`DELETE FROM scheduled_tweets WHERE id = ?`, id)
 	return err
 }
+
+// PublishDue publishes every pending scheduled tweet whose time has come,
+// running the same path as Create. Each tweet is claimed and published in
+// one transaction, so a crash never loses or duplicates it. A tweet the
+// content filter rejects is marked failed; one that fails otherwise is left
+// for the next run and the others are still published; those failures are
+// returned joined. Tweets of suspended or deactivated authors wait until the
+// account is restored.
+func (r *Repository) PublishDue(now time.Time) (int, error) {
+	rows, err := r.DB.Query(`
+		SELECT s.id FROM scheduled_tweets s JOIN users u ON u.id = s.author_id
+		WHERE s.status = ? AND s.publish_at <= ? AND u.suspended_at IS NULL AND u.deactivated_at IS NULL
+		ORDER BY s.publish_at, s.id
+	`, tweet.ScheduledPending, now)
+	if err != nil {
+		return 0, err
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	published := 0
+	var errs []error
+	for _, id := range ids {
+		ok, err := r.publishScheduled(id)
+		if err != nil {
+			// Keep going: one failing tweet must not hold back the rest.
+			errs = append(errs, fmt.Errorf("scheduled tweet %d: %w", id, err))
+			continue
+		}
+		if ok {
+			published++
+		}
+	}
+	return published, errors.Join(errs...)
+}
+
+func (r *Repository) publishScheduled(id int) (bool, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var authorID int
+	var message string
+	err = tx.QueryRow(`
+		SELECT s.author_id, s.message FROM scheduled_tweets s JOIN users u ON u.id = s.author_id
+		WHERE s.id = ? AND s.status = ? AND u.suspended_at IS NULL AND u.deactivated_at IS NULL
+		FOR UPDATE
+	`, id, tweet.ScheduledPending).Scan(&authorID, &message)
+	if err == sql.ErrNoRows {
+		// Cancelled, published by someone else or its author was
+		// suspended or deactivated in the meantime.
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	tweetID, _, err := r.create(tx, authorID, message, now)
+	if errors.Is(err, ErrRejected) {
+		// Nothing was inserted yet: keep the scheduled tweet as failed so
+		// its author sees why, instead of retrying it forever.
+		_, err := tx.Exec(`UPDATE scheduled_tweets SET status = ?, error = ? WHERE id = ?`, tweet.ScheduledFailed, scheduledRejection, id)
+		if err != nil {
+			return false, err
+		}
+		return false, tx.Commit()
+	}
+	if err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(`DELETE FROM scheduled_tweets WHERE id = ?`, id); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	r.remember(tweetID, authorID, message, now)
+	return true, r.indexPublished(int(tweetID))
+}
+
+func scanScheduled(s scanner) (tweet.ScheduledTweet, error) {
+	var st tweet.ScheduledTweet
+	err := s.Scan(&st.Id, &st.AuthorID, &st.Message, &st.PublishAt, &st.CreatedAt, &st.Status, &st.Error)
+	return st, err
+}
+
+// PublishDraft turns a draft into a tweet through the same path as Create,
+// removing the draft in the same transaction, and returns the new tweet's ID.
+// It returns ErrNotFound when the draft no longer exists and ErrRejected,
+// keeping the draft, when the content filter refuses it.
+func (r *Repository) PublishDraft(draftID int) (int64, filter.Decision, error) {
+	var decision filter.Decision
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return 0, decision, err
+	}
+	defer tx.Rollback()
+
+	var userID int
+	var message string
+	err = tx.QueryRow(`SELECT user_id, message FROM drafts WHERE id = ? FOR UPDATE`, draftID).Scan(&userID, &message)
+	if err == sql.ErrNoRows {
+		return 0, decision, ErrNotFound
+	}
+	if err != nil {
+		return 0, decision, err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM drafts WHERE id = ?`, draftID); err != nil {
+		return 0, decision, err
+	}
+	now := time.Now()
+	tweetID, decision, err := r.create(tx, userID, message, now)
+	if err != nil {
+		return 0, decision, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, decision, err
+	}
+	r.remember(tweetID, userID, message, now)
+	return tweetID, decision, r.indexPublished(int(tweetID))
+}
+
+// shortLink is a URL of a message replaced by a short link. Start and End are
+// rune offsets of the short link in the rewritten message.
+type shortLink struct {
+	code       string
+	start, end int
+}
+
+// shorten replaces every URL in message with a short link, reusing the code
+// of URLs shortened before. Short links of this backend already present in
+// the message (e.g. when editing) are kept as they are.
+func (r *Repository) shorten(tx *sql.Tx, message string) (string, []shortLink, error) {
+	matches := links.Detect(message)
+	if len(matches) == 0 {
+		return message, nil, nil
+	}
+
+	prefix := r.LinkBase + "/l/"
+	var out strings.Builder
+	var result []shortLink
+	last := 0
+	for _, m := range matches {
+		out.WriteString(message[last:m.Start])
+		last = m.End
+
+		code, err := r.existingCode(tx, m.URL, prefix)
+		if err != nil {
+			return "", nil, err
+		}
+		if code == "" {
+			if code, err = linkCode(tx, m.URL); err != nil {
+				return "", nil, err
+			}
+		}
+
+		short := prefix + code
+		start := utf8.RuneCountInString(out.String())
+		out.WriteString(short)
+		result = append(result, shortLink{code: code, start: start, end: start + utf8.RuneCountInString(short)})
+	}
+	out.WriteString(message[last:])
+	return out.String(), result, nil
+}
+
+// existingCode returns the code of url when it is already one of our short
+// links, or "" otherwise.
+func (r *Repository) existingCode(tx *sql.Tx, url, prefix string) (string, error) {
+	if !strings.HasPrefix(url, prefix) {
+		return "", nil
+	}
+	code := strings.TrimPrefix(url, prefix)
+
+	var exists bool
+	err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM links WHERE code = ?)`, code).Scan(&exists)
+	if err != nil || !exists {
+		return "", err
+	}
+	return code, nil
+}
+
+// linkCode returns the short code for url, creating one if needed.
+func linkCode(tx *sql.Tx, url string) (string, error) {
+	sum := sha256.Sum256([]byte(url))
+	hash := hex.EncodeToString(sum[:])
+
+	var code string
+	err := tx.QueryRow(`SELECT code FROM links WHERE url_hash = ? AND url = ? LIMIT 1`, hash, url).Scan(&code)
+	if err == nil {
+		return code, nil
+	}
+	if err != sql.ErrNoRows {
+		return "", err
+	}
+
+	// Codes are random; retry on the unlikely collision.
+	for attempt := 0; attempt < 5; attempt++ {
+		code, err := links.NewCode()
+		if err != nil {
+			return "", err
+		}
+		result, err := tx.Exec(`
+			INSERT IGNORE INTO links (code, url, url_hash, created_at) VALUES (?, ?, ?, ?)
+		`, code, url, hash, time.Now())
+		if err != nil {
+			return "", err
+		}
+		if affected, err := result.RowsAffected(); err != nil || affected == 1 {
+			return code, err
+		}
+	}
+	return "", errors.New("could not generate a unique link code")
+}
+
+// saveLinks replaces the link entities stored for a tweet.
+func saveLinks(tx *sql.Tx, tweetID int64, shortLinks []shortLink) error {
+	if _, err := tx.Exec(`DELETE FROM tweet_links WHERE tweet_id = ?`, tweetID); err != nil {
+		return err
+	}
+	for i, l := range shortLinks {
+		_, err := tx.Exec(`
+			INSERT INTO tweet_links (tweet_id, position, code, start_index, end_index) VALUES (?, ?, ?, ?, ?)
+		`, tweetID, i+1, l.code, l.start, l.end)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -3,27 +3,61 @@ package userRepo
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"strings"
+	"time"
 	"ualabackend/entities/user"
+	"ualabackend/search"
+)
+
+// candidateFactor is how many autocomplete candidates are looked up per
+// result returned.
+const candidateFactor = 2
+
+const userColumns = `id, name, followers_id, following_id, feed, protected, allow_dms_from_anyone, role,
+	followers_count, following_count, tweet_count, deactivated_at, suspended_at, reach_restricted`
+
+var (
+	// ErrNotDeactivated is returned when reactivating an active account.
+	ErrNotDeactivated = errors.New("account is not deactivated")
+	// ErrGracePeriodOver is returned when reactivating after the grace period.
+	ErrGracePeriodOver = errors.New("deactivation grace period is over")
 )
 
 type Repository struct {
-	DB *sql.DB
+	DB    *sql.DB
+	Names *search.NameIndex
+	// Tweets, when set, is the tweet search index, whose author names are
+	// kept in line on rename.
+	Tweets search.Index
 }
 
-func NewRepository(db *sql.DB) *Repository {
-	return &Repository{DB: db}
+func NewRepository(db *sql.DB, names *search.NameIndex) *Repository {
+	return &Repository{DB: db, Names: names}
 }
 
-func (r *Repository) Create(username string) error {
-	_, err := r.DB.Exec(`
+// Create inserts a user and returns its ID.
+func (r *Repository) Create(username string) (int, error) {
+	result, err := r.DB.Exec(`
 		INSERT INTO users (name, followers_id, following_id, feed)
 		VALUES (?, '[]', '[]', '[]')
 	`, username)
-	return err
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	r.Names.Set(int(id), username)
+	return int(id), nil
 }
 
+// GetAll returns every active user. Deactivated and suspended accounts are
+// left out.
 func (r *Repository) GetAll() ([]user.User, error) {
-	query := `SELECT id, name, followers_id, following_id, feed FROM users ORDER BY id ASC`
+	query := `SELECT ` + userColumns + ` FROM users WHERE deactivated_at IS NULL AND suspended_at IS NULL ORDER BY id ASC`
 	rows, err := r.DB.Query(query)
 	if err != nil {
 		return nil, err
@@ -32,40 +66,351 @@ func (r *Repository) GetAll() ([]user.User, error) {
 
 	var users []user.User
 	for rows.Next() {
-		var u user.User
-		var followersID, followingID, feed sql.NullString
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, nil
+}
+
+// GetByID returns the user, including deactivated and suspended accounts;
+// callers check DeactivatedAt and SuspendedAt when those must be hidden.
+func (r *Repository) GetByID(id int) (*user.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = ?`
+	row := r.DB.QueryRow(query, id)
+
+	u, err := scanUser(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *Repository) Update(id int, newName string) error {
+	query := `UPDATE users SET name = ? WHERE id = ?`
+	_, err := r.DB.Exec(query, newName, id)
+	if err != nil {
+		return err
+	}
+	r.Names.Set(id, newName)
+	if r.Tweets != nil {
+		return r.Tweets.RenameAuthor(id, newName)
+	}
+	return nil
+}
+
+// SetProtected changes whether the account's tweets are only visible to
+// approved followers.
+func (r *Repository) SetProtected(id int, protected bool) error {
+	query := `UPDATE users SET protected = ? WHERE id = ?`
+	_, err := r.DB.Exec(query, protected, id)
+	return err
+}
+
+// SetAllowDMs changes whether anyone may start a DM with the user, instead of
+// mutual follows only.
+func (r *Repository) SetAllowDMs(id int, allow bool) error {
+	query := `UPDATE users SET allow_dms_from_anyone = ? WHERE id = ?`
+	_, err := r.DB.Exec(query, allow, id)
+	return err
+}
+
+// SetRole changes the role of the user.
+func (r *Repository) SetRole(id int, role string) error {
+	_, err := r.DB.Exec(`UPDATE users SET role = ? WHERE id = ?`, role, id)
+	return err
+}
+
+// Account filters accepted by Accounts.
+const (
+	AccountsAll         = "all"
+	AccountsActive      = "active"
+	AccountsSuspended   = "suspended"
+	AccountsDeactivated = "deactivated"
+)
+
+var accountFilters = map[string]string{
+	AccountsAll:         `TRUE`,
+	AccountsActive:      `deactivated_at IS NULL AND suspended_at IS NULL`,
+	AccountsSuspended:   `suspended_at IS NOT NULL`,
+	AccountsDeactivated: `deactivated_at IS NOT NULL`,
+}
+
+// ValidAccountFilter reports whether status is accepted by Accounts.
+func ValidAccountFilter(status string) bool {
+	_, ok := accountFilters[status]
+	return ok
+}
+
+// Accounts lists users with their role and moderation state, by ID. status
+// is one of the Accounts* filters.
+func (r *Repository) Accounts(status string, limit, offset int) ([]user.Account, error) {
+	where, ok := accountFilters[status]
+	if !ok {
+		where = accountFilters[AccountsAll]
+	}
+	rows, err := r.DB.Query(`
+		SELECT id, name, role, protected, followers_count, following_count, tweet_count,
+			reach_restricted, deactivated_at, suspended_at
+		FROM users
+		WHERE `+where+`
+		ORDER BY id ASC
+		LIMIT ? OFFSET ?
+	`, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := []user.Account{}
+	for rows.Next() {
+		var a user.Account
+		var deactivatedAt, suspendedAt sql.NullTime
+		err := rows.Scan(&a.Id, &a.Name, &a.Role, &a.Protected, &a.FollowersCount, &a.FollowingCount, &a.TweetCount,
+			&a.ReachRestricted, &deactivatedAt, &suspendedAt)
+		if err != nil {
+			return nil, err
+		}
+		if deactivatedAt.Valid {
+			a.DeactivatedAt = &deactivatedAt.Time
+		}
+		if suspendedAt.Valid {
+			a.SuspendedAt = &suspendedAt.Time
+		}
+		accounts = append(accounts, a)
+	}
+	return accounts, rows.Err()
+}
+
+// Deactivate hides the account everywhere. It can be reactivated during the
+// grace period, after which the deletion job removes it for good.
+func (r *Repository) Deactivate(id int) error {
+	query := `UPDATE users SET deactivated_at = ? WHERE id = ? AND deactivated_at IS NULL`
+	_, err := r.DB.Exec(query, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	r.Names.Remove(id)
+	return nil
+}
+
+// Reactivate undoes a deactivation made less than grace ago.
+func (r *Repository) Reactivate(id int, grace time.Duration) error {
+	u, err := r.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if u == nil || u.DeactivatedAt == nil {
+		return ErrNotDeactivated
+	}
+	if time.Since(*u.DeactivatedAt) > grace {
+		return ErrGracePeriodOver
+	}
+
+	query := `UPDATE users SET deactivated_at = NULL WHERE id = ?`
+	if _, err := r.DB.Exec(query, id); err != nil {
+		return err
+	}
+	r.Names.Set(id, u.Name)
+	return nil
+}
+
+// DeactivatedBefore returns the accounts deactivated before cutoff, which are
+// due for deletion.
+func (r *Repository) DeactivatedBefore(cutoff time.Time) ([]int, error) {
+	rows, err := r.DB.Query(`SELECT id FROM users WHERE deactivated_at < ? ORDER BY id`, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-		if err := rows.Scan(&u.Id, &u.Name, &followersID, &followingID, &feed); err != nil {
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
 			return nil, err
 		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// IndexNames loads the name of every active user into the autocomplete
+// index, ranked by follower count.
+func (r *Repository) IndexNames() error {
+	rows, err := r.DB.Query(`SELECT id, name, followers_count FROM users WHERE deactivated_at IS NULL AND suspended_at IS NULL`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, followers int
+		var name string
+		if err := rows.Scan(&id, &name, &followers); err != nil {
+			return err
+		}
+		r.Names.Set(id, name)
+		r.Names.SetRank(id, followers)
+	}
+	return rows.Err()
+}
+
+// RankNames refreshes the rank of every indexed user with their current
+// follower count.
+func (r *Repository) RankNames() error {
+	rows, err := r.DB.Query(`SELECT id, followers_count FROM users WHERE deactivated_at IS NULL AND suspended_at IS NULL`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, followers int
+		if err := rows.Scan(&id, &followers); err != nil {
+			return err
+		}
+		r.Names.SetRank(id, followers)
+	}
+	return rows.Err()
+}
+
+// SearchByPrefix returns users whose name has a word starting with prefix:
+// accounts the viewer already follows come first, then the most followed.
+// Only the best ranked matches of each group are looked up, so short
+// prefixes cost the same as long ones. A viewerID of 0 means an anonymous
+// search.
+func (r *Repository) SearchByPrefix(prefix string, viewerID, limit int) ([]user.UserSummary, error) {
+	// Ranks lag behind follower counts and the index may still hold users
+	// suspended or deactivated meanwhile, so a few extra candidates are
+	// ranked in SQL.
+	n := limit * candidateFactor
 
-		if followersID.Valid {
-			u.Followers_id = json.RawMessage(followersID.String)
+	var ids []int
+	seen := map[int]bool{}
+	if viewerID != 0 {
+		followed, err := r.followedIDs(viewerID)
+		if err != nil {
+			return nil, err
 		}
-		if followingID.Valid {
-			u.Following_id = json.RawMessage(followingID.String)
+		for _, id := range r.Names.Top(prefix, n, func(id int) bool { return followed[id] }) {
+			seen[id] = true
+			ids = append(ids, id)
 		}
-		if feed.Valid {
-			u.Feed = json.RawMessage(feed.String)
+	}
+	for _, id := range r.Names.Top(prefix, n, nil) {
+		if !seen[id] {
+			ids = append(ids, id)
 		}
+	}
+	if len(ids) == 0 {
+		return []user.UserSummary{}, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	query := `
+		SELECT u.id, u.name, u.followers_count,
+			EXISTS(SELECT 1 FROM follows f WHERE f.follower_id = ? AND f.followed_id = u.id) AS followed
+		FROM users u
+		WHERE u.id IN (` + placeholders + `) AND u.deactivated_at IS NULL AND u.suspended_at IS NULL
+		ORDER BY followed DESC, u.followers_count DESC, u.id
+		LIMIT ?
+	`
+	args := []interface{}{viewerID}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	args = append(args, limit)
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
+	users := []user.UserSummary{}
+	for rows.Next() {
+		var u user.UserSummary
+		if err := rows.Scan(&u.Id, &u.Name, &u.FollowersCount, &u.FollowedByViewer); err != nil {
+			return nil, err
+		}
+		r.Names.SetRank(u.Id, u.FollowersCount)
 		users = append(users, u)
 	}
-	return users, nil
+	return users, rows.Err()
 }
 
-func (r *Repository) GetByID(id int) (*user.User, error) {
-	query := `SELECT id, name, followers_id, following_id, feed FROM users WHERE id = ?`
-	row := r.DB.QueryRow(query, id)
+// followedIDs returns the users followerID follows.
+func (r *Repository) followedIDs(followerID int) (map[int]bool, error) {
+	rows, err := r.DB.Query(`SELECT followed_id FROM follows WHERE follower_id = ?`, followerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := map[int]bool{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids[id] = true
+	}
+	return ids, rows.Err()
+}
+
+// RecomputeCounters rebuilds followers_count, following_count and tweet_count
+// for every user from the follows and tweets tables.
+func (r *Repository) RecomputeCounters() (int64, error) {
+	result, err := r.DB.Exec(`
+		UPDATE users u SET
+			followers_count = (SELECT COUNT(*) FROM follows f WHERE f.followed_id = u.id),
+			following_count = (SELECT COUNT(*) FROM follows f WHERE f.follower_id = u.id),
+			tweet_count = (SELECT COUNT(*) FROM tweets t WHERE t.author_id = u.id AND t.deleted_at IS NULL)
+	`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
 
+// RebuildTimelines rebuilds the followers_id, following_id and feed arrays of
+// every user from the follows and tweets tables.
+func (r *Repository) RebuildTimelines() (int64, error) {
+	result, err := r.DB.Exec(`
+		UPDATE users u SET
+			followers_id = (SELECT COALESCE(JSON_ARRAYAGG(f.follower_id), JSON_ARRAY()) FROM follows f WHERE f.followed_id = u.id),
+			following_id = (SELECT COALESCE(JSON_ARRAYAGG(f.followed_id), JSON_ARRAY()) FROM follows f WHERE f.follower_id = u.id),
+			feed = (
+				SELECT COALESCE(JSON_ARRAYAGG(t.id), JSON_ARRAY())
+				FROM follows f JOIN tweets t ON t.author_id = f.followed_id
+				WHERE f.follower_id = u.id AND t.deleted_at IS NULL
+			)
+	`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanUser reads a row selected with userColumns.
+func scanUser(s scanner) (user.User, error) {
 	var u user.User
 	var followersID, followingID, feed sql.NullString
+	var deactivatedAt, suspendedAt sql.NullTime
 
-	if err := row.Scan(&u.Id, &u.Name, &followersID, &followingID, &feed); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
-		return nil, err
+	err := s.Scan(&u.Id, &u.Name, &followersID, &followingID, &feed, &u.Protected, &u.AllowDMs, &u.Role,
+		&u.FollowersCount, &u.FollowingCount, &u.TweetCount, &deactivatedAt, &suspendedAt, &u.ReachRestricted)
+	if err != nil {
+		return u, err
 	}
 
 	if followersID.Valid {
@@ -77,18 +422,11 @@ func (r *Repository) GetByID(id int) (*user.User, error) {
 	if feed.Valid {
 		u.Feed = json.RawMessage(feed.String)
 	}
-
-	return &u, nil
-}
-
-func (r *Repository) Update(id int, newName string) error {
-	query := `UPDATE users SET name = ? WHERE id = ?`
-	_, err := r.DB.Exec(query, newName, id)
-	return err
-}
-
-func (r *Repository) Delete(id int) error {
-	query := `DELETE FROM users WHERE id = ?`
-	_, err := r.DB.Exec(query, id)
-	return err
+	if deactivatedAt.Valid {
+		u.DeactivatedAt = &deactivatedAt.Time
+	}
+	if suspendedAt.Valid {
+		u.SuspendedAt = &suspendedAt.Time
+	}
+	return u, nil
 }
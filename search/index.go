@@ -0,0 +1,38 @@
+package search
+
+import "time"
+
+// Document is the indexable representation of a tweet.
+type Document struct {
+	ID        int
+	AuthorID  int
+	Author    string
+	Text      string
+	CreatedAt time.Time
+}
+
+// Result is a single search hit with its relevance score.
+type Result struct {
+	ID    int
+	Score float64
+}
+
+// Index is implemented by every search backend. The tweet repository keeps it
+// in sync on create, update and delete.
+type Index interface {
+	Add(doc Document) error
+	Remove(id int) error
+	// RenameAuthor updates the author name of every document by authorID.
+	RenameAuthor(authorID int, name string) error
+	Search(q Query) ([]Result, error)
+}
+
+// Rebuild loads all the given documents into the index, typically at startup.
+func Rebuild(index Index, docs []Document) error {
+	for _, d := range docs {
+		if err := index.Add(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
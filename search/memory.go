@@ -0,0 +1,252 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+type memoryDoc struct {
+	authorID  int
+	author    string
+	createdAt time.Time
+	terms     []string
+	tags      []string
+}
+
+// MemoryIndex is an embedded inverted index kept entirely in memory. It needs
+// no external service and is rebuilt from the tweets table at startup.
+type MemoryIndex struct {
+	mu       sync.RWMutex
+	docs     map[int]*memoryDoc
+	postings map[string]map[int][]int
+	tags     map[string]map[int]struct{}
+}
+
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{
+		docs:     map[int]*memoryDoc{},
+		postings: map[string]map[int][]int{},
+		tags:     map[string]map[int]struct{}{},
+	}
+}
+
+func (m *MemoryIndex) Add(doc Document) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remove(doc.ID)
+
+	d := &memoryDoc{
+		authorID:  doc.AuthorID,
+		author:    NormalizeName(doc.Author),
+		createdAt: doc.CreatedAt,
+		terms:     Tokenize(doc.Text),
+		tags:      Hashtags(doc.Text),
+	}
+	m.docs[doc.ID] = d
+
+	for pos, term := range d.terms {
+		if m.postings[term] == nil {
+			m.postings[term] = map[int][]int{}
+		}
+		m.postings[term][doc.ID] = append(m.postings[term][doc.ID], pos)
+	}
+	for _, tag := range d.tags {
+		if m.tags[tag] == nil {
+			m.tags[tag] = map[int]struct{}{}
+		}
+		m.tags[tag][doc.ID] = struct{}{}
+	}
+	return nil
+}
+
+func (m *MemoryIndex) RenameAuthor(authorID int, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = NormalizeName(name)
+	for _, d := range m.docs {
+		if d.authorID == authorID {
+			d.author = name
+		}
+	}
+	return nil
+}
+
+func (m *MemoryIndex) Remove(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remove(id)
+	return nil
+}
+
+func (m *MemoryIndex) remove(id int) {
+	d, ok := m.docs[id]
+	if !ok {
+		return
+	}
+	for _, term := range d.terms {
+		delete(m.postings[term], id)
+		if len(m.postings[term]) == 0 {
+			delete(m.postings, term)
+		}
+	}
+	for _, tag := range d.tags {
+		delete(m.tags[tag], id)
+		if len(m.tags[tag]) == 0 {
+			delete(m.tags, tag)
+		}
+	}
+	delete(m.docs, id)
+}
+
+func (m *MemoryIndex) Search(q Query) ([]Result, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	required := append([]string{}, q.Terms...)
+	for _, phrase := range q.Phrases {
+		required = append(required, phrase...)
+	}
+
+	var results []Result
+	for id, d := range m.candidates(required, q.Tags) {
+		if q.ExcludeAuthors[d.authorID] {
+			continue
+		}
+		if q.From != "" && d.author != q.From {
+			continue
+		}
+		if q.FromID != 0 && d.authorID != q.FromID {
+			continue
+		}
+		if !q.Since.IsZero() && d.createdAt.Before(q.Since) {
+			continue
+		}
+		if !q.Until.IsZero() && d.createdAt.After(q.Until) {
+			continue
+		}
+		if !m.matchesPhrases(id, q.Phrases) {
+			continue
+		}
+		results = append(results, Result{ID: id, Score: m.score(id, d, required, q.Tags)})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if q.Sort != SortRecent && a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		ta, tb := m.docs[a.ID].createdAt, m.docs[b.ID].createdAt
+		if !ta.Equal(tb) {
+			return ta.After(tb)
+		}
+		return a.ID > b.ID
+	})
+
+	return paginate(results, q.Offset, q.Limit), nil
+}
+
+// candidates returns the documents containing every required term and tag.
+// With no terms or tags every document is a candidate.
+func (m *MemoryIndex) candidates(terms, tags []string) map[int]*memoryDoc {
+	var sets []map[int]struct{}
+	for _, term := range terms {
+		set := map[int]struct{}{}
+		for id := range m.postings[term] {
+			set[id] = struct{}{}
+		}
+		sets = append(sets, set)
+	}
+	for _, tag := range tags {
+		sets = append(sets, m.tags[tag])
+	}
+
+	out := map[int]*memoryDoc{}
+	if len(sets) == 0 {
+		for id, d := range m.docs {
+			out[id] = d
+		}
+		return out
+	}
+
+	sort.Slice(sets, func(i, j int) bool { return len(sets[i]) < len(sets[j]) })
+	for id := range sets[0] {
+		inAll := true
+		for _, set := range sets[1:] {
+			if _, ok := set[id]; !ok {
+				inAll = false
+				break
+			}
+		}
+		if inAll {
+			out[id] = m.docs[id]
+		}
+	}
+	return out
+}
+
+func (m *MemoryIndex) matchesPhrases(id int, phrases [][]string) bool {
+	for _, phrase := range phrases {
+		found := false
+		for _, start := range m.postings[phrase[0]][id] {
+			match := true
+			for offset, term := range phrase[1:] {
+				if !containsInt(m.postings[term][id], start+offset+1) {
+					match = false
+					break
+				}
+			}
+			if match {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// score is a length-normalized TF-IDF over the query terms and tags.
+func (m *MemoryIndex) score(id int, d *memoryDoc, terms, tags []string) float64 {
+	total := float64(len(m.docs))
+	var score float64
+	for _, term := range terms {
+		df := float64(len(m.postings[term]))
+		tf := float64(len(m.postings[term][id]))
+		score += tf * math.Log(1+total/df)
+	}
+	for _, tag := range tags {
+		df := float64(len(m.tags[tag]))
+		score += math.Log(1 + total/df)
+	}
+	if len(d.terms) > 0 {
+		score /= math.Sqrt(float64(len(d.terms)))
+	}
+	return score
+}
+
+func containsInt(values []int, v int) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+func paginate(results []Result, offset, limit int) []Result {
+	if offset >= len(results) {
+		return []Result{}
+	}
+	results = results[offset:]
+	if limit > 0 && limit < len(results) {
+		results = results[:limit]
+	}
+	return results
+}
@@ -0,0 +1,105 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTestIndex(t *testing.T) *MemoryIndex {
+	t.Helper()
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	docs := []Document{
+		{ID: 1, AuthorID: 10, Author: "Juan Pérez", Text: "Hola mundo desde #golang", CreatedAt: base},
+		{ID: 2, AuthorID: 20, Author: "Ana", Text: "El mundo de Go: https://github.com/golang/go", CreatedAt: base.Add(time.Hour)},
+		{ID: 3, AuthorID: 10, Author: "Juan Pérez", Text: "mundo mundo mundo", CreatedAt: base.Add(2 * time.Hour)},
+		{ID: 4, AuthorID: 30, Author: "Luis", Text: "Canción del mundo hola", CreatedAt: base.Add(24 * time.Hour)},
+	}
+	m := NewMemoryIndex()
+	if err := Rebuild(m, docs); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func ids(results []Result) []int {
+	out := []int{}
+	for _, r := range results {
+		out = append(out, r.ID)
+	}
+	return out
+}
+
+func TestMemoryIndexSearch(t *testing.T) {
+	m := newTestIndex(t)
+
+	tests := []struct {
+		name  string
+		query Query
+		want  []int
+	}{
+		{"every term is required, ties by recency", ParseQuery("hola mundo"), []int{4, 1}},
+		{"frequent and short documents rank first", ParseQuery("mundo"), []int{3, 4, 1, 2}},
+		{"recent sort ignores score", Query{Terms: []string{"mundo"}, Sort: SortRecent}, []int{4, 3, 2, 1}},
+		{"phrase in order", ParseQuery(`"hola mundo"`), []int{1}},
+		{"phrase out of order", ParseQuery(`"mundo hola"`), []int{4}},
+		{"hashtag", ParseQuery("#golang"), []int{1}},
+		{"domain of a URL", ParseQuery("github.com"), []int{2}},
+		{"diacritics are ignored", ParseQuery("cancion"), []int{4}},
+		{"from a quoted name", ParseQuery(`mundo from:"juan perez"`), []int{3, 1}},
+		{"from an author ID", ParseQuery("mundo from:20"), []int{2}},
+		{"from an unknown author", ParseQuery("mundo from:nadie"), []int{}},
+		{"since and until", ParseQuery("mundo since:2024-03-01 until:2024-03-01"), []int{3, 1, 2}},
+		{"excluded authors", Query{Terms: []string{"mundo"}, ExcludeAuthors: map[int]bool{10: true}}, []int{4, 2}},
+		{"offset and limit", Query{Terms: []string{"mundo"}, Sort: SortRecent, Offset: 1, Limit: 2}, []int{3, 2}},
+		{"offset past the end", Query{Terms: []string{"mundo"}, Offset: 10}, []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := m.Search(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ids(results); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search(%+v) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryIndexUpdates(t *testing.T) {
+	m := newTestIndex(t)
+	search := func(raw string) []int {
+		t.Helper()
+		results, err := m.Search(ParseQuery(raw))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ids(results)
+	}
+
+	if err := m.RenameAuthor(10, "Juana Pérez"); err != nil {
+		t.Fatal(err)
+	}
+	if got := search(`from:"juan perez"`); len(got) != 0 {
+		t.Errorf("old name still matches %v", got)
+	}
+	if got, want := search(`from:"Juana Pérez"`), []int{3, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("new name matches %v, want %v", got, want)
+	}
+
+	// Adding a document again replaces it.
+	if err := m.Add(Document{ID: 2, AuthorID: 20, Author: "Ana", Text: "adiós"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := search("github"); len(got) != 0 {
+		t.Errorf("replaced text still matches %v", got)
+	}
+
+	if err := m.Remove(4); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := search("hola"), []int{1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after removal got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,149 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+type nameEntry struct {
+	key string
+	id  int
+}
+
+// NameIndex answers prefix lookups over user names. Every word of a name is
+// indexed, so "per" matches both "Pérez Juan" and "Juan Pérez". Each user
+// also carries a rank, such as their follower count, so the best matches of
+// a short prefix can be picked without looking at all of them.
+type NameIndex struct {
+	mu      sync.RWMutex
+	entries []nameEntry
+	keys    map[int][]string
+	ranks   map[int]int
+}
+
+func NewNameIndex() *NameIndex {
+	return &NameIndex{keys: map[int][]string{}, ranks: map[int]int{}}
+}
+
+// Set indexes name for the given user, replacing any previous value. The
+// user keeps their rank, if any.
+func (n *NameIndex) Set(id int, name string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	rank := n.ranks[id]
+	n.remove(id)
+	n.ranks[id] = rank
+
+	words := Tokenize(name)
+	var keys []string
+	for i := range words {
+		keys = append(keys, strings.Join(words[i:], " "))
+	}
+	n.keys[id] = keys
+
+	for _, key := range keys {
+		e := nameEntry{key: key, id: id}
+		i := sort.Search(len(n.entries), func(i int) bool { return !less(n.entries[i], e) })
+		n.entries = append(n.entries, nameEntry{})
+		copy(n.entries[i+1:], n.entries[i:])
+		n.entries[i] = e
+	}
+}
+
+func (n *NameIndex) Remove(id int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.remove(id)
+}
+
+func (n *NameIndex) remove(id int) {
+	if _, ok := n.keys[id]; !ok {
+		return
+	}
+	kept := n.entries[:0]
+	for _, e := range n.entries {
+		if e.id != id {
+			kept = append(kept, e)
+		}
+	}
+	n.entries = kept
+	delete(n.keys, id)
+	delete(n.ranks, id)
+}
+
+// SetRank sets the rank of an indexed user. Unknown users are ignored.
+func (n *NameIndex) SetRank(id, rank int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if _, ok := n.keys[id]; ok {
+		n.ranks[id] = rank
+	}
+}
+
+// Prefix returns up to max distinct user IDs with a name word starting with
+// prefix, in alphabetical order of the matched key. A max of 0 or less
+// returns every match.
+func (n *NameIndex) Prefix(prefix string, max int) []int {
+	prefix = strings.Join(Tokenize(prefix), " ")
+	if prefix == "" {
+		return nil
+	}
+
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	start := sort.Search(len(n.entries), func(i int) bool { return n.entries[i].key >= prefix })
+
+	ids := []int{}
+	seen := map[int]bool{}
+	for _, e := range n.entries[start:] {
+		if !strings.HasPrefix(e.key, prefix) || (max > 0 && len(ids) >= max) {
+			break
+		}
+		if !seen[e.id] {
+			seen[e.id] = true
+			ids = append(ids, e.id)
+		}
+	}
+	return ids
+}
+
+// Top returns up to max distinct user IDs with a name word starting with
+// prefix, highest rank first and then by ID. When keep is not nil, only the
+// IDs it accepts are considered.
+func (n *NameIndex) Top(prefix string, max int, keep func(id int) bool) []int {
+	if max <= 0 {
+		return []int{}
+	}
+	ids := []int{}
+	for _, id := range n.Prefix(prefix, 0) {
+		if keep == nil || keep(id) {
+			ids = append(ids, id)
+		}
+	}
+
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	sort.Slice(ids, func(i, j int) bool {
+		if ri, rj := n.ranks[ids[i]], n.ranks[ids[j]]; ri != rj {
+			return ri > rj
+		}
+		return ids[i] < ids[j]
+	})
+	if len(ids) > max {
+		ids = ids[:max]
+	}
+	return ids
+}
+
+func less(a, b nameEntry) bool {
+	if a.key != b.key {
+		return a.key < b.key
+	}
+	return a.id < b.id
+}
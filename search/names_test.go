@@ -0,0 +1,61 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNameIndexTop(t *testing.T) {
+	n := NewNameIndex()
+	n.Set(1, "Ana Pérez")
+	n.Set(2, "Andrés")
+	n.Set(3, "Juan Anaya")
+	n.Set(4, "Luis")
+	n.Set(5, "Anabel")
+	n.SetRank(1, 10)
+	n.SetRank(2, 50)
+	n.SetRank(3, 10)
+	n.SetRank(4, 99)
+
+	tests := []struct {
+		name   string
+		prefix string
+		max    int
+		keep   func(id int) bool
+		want   []int
+	}{
+		{name: "highest rank first, then by ID", prefix: "an", max: 10, want: []int{2, 1, 3, 5}},
+		{name: "bounded", prefix: "an", max: 2, want: []int{2, 1}},
+		{name: "matches later words", prefix: "per", max: 10, want: []int{1}},
+		{name: "filtered", prefix: "an", max: 10, keep: func(id int) bool { return id != 2 }, want: []int{1, 3, 5}},
+		{name: "no matches", prefix: "zz", max: 10, want: []int{}},
+		{name: "no room", prefix: "an", max: 0, want: []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := n.Top(tt.prefix, tt.max, tt.keep); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Top(%q, %d) = %v, want %v", tt.prefix, tt.max, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNameIndexKeepsRankOnRename(t *testing.T) {
+	n := NewNameIndex()
+	n.Set(1, "Ana")
+	n.Set(2, "Andrés")
+	n.SetRank(1, 10)
+	n.Set(1, "Anabel")
+
+	if got, want := n.Top("an", 10, nil), []int{1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Top after rename = %v, want %v", got, want)
+	}
+
+	n.Remove(1)
+	n.SetRank(1, 100)
+	n.Set(1, "Ana")
+	n.SetRank(2, 5)
+	if got, want := n.Top("an", 10, nil), []int{2, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Top after removal = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,122 @@
+package search
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SortRelevance = "relevance"
+	SortRecent    = "recent"
+)
+
+// Query is a parsed search expression.
+type Query struct {
+	Terms   []string
+	Phrases [][]string
+	Tags    []string
+	Since   time.Time
+	Until   time.Time
+	Sort    string
+	Limit   int
+	Offset  int
+
+	// From restricts results to the author with this normalized name, and
+	// FromID to the author with this ID.
+	From   string
+	FromID int
+
+	// ExcludeAuthors hides the tweets of these authors, e.g. blocked users.
+	ExcludeAuthors map[int]bool
+}
+
+// ParseQuery parses expressions such as:
+//
+//	golang "hola mundo" #go from:usuario1 since:2024-01-01 until:2024-12-31
+//
+// from: takes an author's name, quoted when it has spaces
+// (from:"Juan Pérez"), or an author's ID (from:42). Dates are inclusive and
+// use the YYYY-MM-DD format.
+func ParseQuery(raw string) Query {
+	q := Query{Sort: SortRelevance}
+
+	for _, part := range splitQuery(raw) {
+		if strings.HasPrefix(part, `"`) {
+			phrase := Tokenize(strings.Trim(part, `"`))
+			if len(phrase) > 0 {
+				q.Phrases = append(q.Phrases, phrase)
+			}
+			continue
+		}
+
+		lower := strings.ToLower(part)
+		switch {
+		case strings.HasPrefix(lower, "from:"):
+			from := part[len("from:"):]
+			if id, err := strconv.Atoi(from); err == nil && id > 0 {
+				q.FromID = id
+			} else {
+				q.From = NormalizeName(strings.Trim(from, `"`))
+			}
+		case strings.HasPrefix(lower, "since:"):
+			if t, err := time.Parse("2006-01-02", strings.TrimPrefix(lower, "since:")); err == nil {
+				q.Since = t
+			}
+		case strings.HasPrefix(lower, "until:"):
+			if t, err := time.Parse("2006-01-02", strings.TrimPrefix(lower, "until:")); err == nil {
+				q.Until = t.Add(24*time.Hour - time.Nanosecond)
+			}
+		case strings.HasPrefix(lower, "#"):
+			q.Tags = append(q.Tags, Tokenize(part)...)
+		default:
+			q.Terms = append(q.Terms, Tokenize(part)...)
+		}
+	}
+
+	return q
+}
+
+// IsEmpty reports whether the query has no criteria at all.
+func (q Query) IsEmpty() bool {
+	return len(q.Terms) == 0 && len(q.Phrases) == 0 && len(q.Tags) == 0 &&
+		q.From == "" && q.FromID == 0 && q.Since.IsZero() && q.Until.IsZero()
+}
+
+// splitQuery splits on whitespace while keeping quoted phrases together. A
+// quote right after an operator such as from: stays in the same part.
+func splitQuery(raw string) []string {
+	var parts []string
+	var current strings.Builder
+	inQuotes := false
+
+	flush := func() {
+		if current.Len() > 0 {
+			parts = append(parts, current.String())
+			current.Reset()
+		}
+	}
+
+	for _, r := range raw {
+		switch {
+		case r == '"':
+			if inQuotes {
+				current.WriteRune(r)
+				flush()
+			} else {
+				if !strings.HasSuffix(current.String(), ":") {
+					flush()
+				}
+				current.WriteRune(r)
+			}
+			inQuotes = !inQuotes
+		case (r == ' ' || r == '\t' || r == '\n') && !inQuotes:
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+
+	return parts
+}
@@ -0,0 +1,91 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseQuery(t *testing.T) {
+	day := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	tests := []struct {
+		name string
+		raw  string
+		want Query
+	}{
+		{
+			name: "terms are tokenized and normalized",
+			raw:  "Canción github.com",
+			want: Query{Terms: []string{"cancion", "github", "com"}},
+		},
+		{
+			name: "phrases and hashtags",
+			raw:  `"Hola Mundo" #GoLang`,
+			want: Query{Phrases: [][]string{{"hola", "mundo"}}, Tags: []string{"golang"}},
+		},
+		{
+			name: "from a single-word name",
+			raw:  "from:Usuario1 golang",
+			want: Query{Terms: []string{"golang"}, From: "usuario1"},
+		},
+		{
+			name: "from a quoted name with spaces",
+			raw:  `from:"Juan  Pérez" golang`,
+			want: Query{Terms: []string{"golang"}, From: "juan perez"},
+		},
+		{
+			name: "from an author ID",
+			raw:  "from:42",
+			want: Query{FromID: 42},
+		},
+		{
+			name: "from a quoted number is a name",
+			raw:  `from:"42"`,
+			want: Query{From: "42"},
+		},
+		{
+			name: "dates are inclusive",
+			raw:  "since:2024-01-01 until:2024-12-31",
+			want: Query{Since: day("2024-01-01"), Until: day("2025-01-01").Add(-time.Nanosecond)},
+		},
+		{
+			name: "invalid dates are ignored",
+			raw:  "since:ayer",
+			want: Query{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.want.Sort = SortRelevance
+			if got := ParseQuery(tt.raw); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseQuery(%q) = %+v, want %+v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryIsEmpty(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want bool
+	}{
+		{"", true},
+		{"   ", true},
+		{"since:ayer", true},
+		{"golang", false},
+		{"from:42", false},
+		{`from:"Juan Pérez"`, false},
+	}
+	for _, tt := range tests {
+		if got := ParseQuery(tt.raw).IsEmpty(); got != tt.want {
+			t.Errorf("ParseQuery(%q).IsEmpty() = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+}
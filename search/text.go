@@ -0,0 +1,53 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalize lowercases s and strips diacritics so that "Canción" and
+// "cancion" compare equal.
+func Normalize(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(strings.ToLower(s)) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// NormalizeName normalizes a name like Normalize and collapses its
+// whitespace, so author names compare equal however they were spaced.
+func NormalizeName(name string) string {
+	return strings.Join(strings.Fields(Normalize(name)), " ")
+}
+
+// Tokenize splits text into normalized words, ignoring punctuation and the
+// leading '#' of hashtags.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(Normalize(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+}
+
+// Hashtags returns the normalized hashtags present in text, without the '#'.
+func Hashtags(text string) []string {
+	var tags []string
+	seen := map[string]bool{}
+	for _, word := range strings.Fields(text) {
+		if !strings.HasPrefix(word, "#") {
+			continue
+		}
+		tokens := Tokenize(word)
+		if len(tokens) == 0 || seen[tokens[0]] {
+			continue
+		}
+		seen[tokens[0]] = true
+		tags = append(tags, tokens[0])
+	}
+	return tags
+}
@@ -0,0 +1,153 @@
+package suggestions
+
+import (
+	"sync"
+	"time"
+
+	"ualabackend/entities/follow"
+)
+
+// poolSize is how many candidates are precomputed per user. Requests are
+// served from this pool after filtering out accounts followed since, so it
+// holds a couple of pages of the API's maximum size (100).
+const poolSize = 200
+
+// Source provides the follow graph queries the engine is built on.
+type Source interface {
+	FriendsOfFriends(userID, limit int) ([]follow.Suggestion, error)
+	Popular(userID, limit int) ([]follow.Suggestion, error)
+	ExcludedIDs(userID int) (map[int]bool, error)
+}
+
+type entry struct {
+	items      []follow.Suggestion
+	computedAt time.Time
+}
+
+// Service computes who-to-follow recommendations on request and caches them
+// for the TTL. Expired entries are recomputed on the next request and
+// dropped by Prune, so the cache only holds users who asked recently.
+type Service struct {
+	source Source
+	ttl    time.Duration
+
+	mu    sync.RWMutex
+	cache map[int]entry
+}
+
+func NewService(source Source, ttl time.Duration) *Service {
+	return &Service{
+		source: source,
+		ttl:    ttl,
+		cache:  map[int]entry{},
+	}
+}
+
+// Get returns up to limit suggestions for userID, skipping the first offset.
+func (s *Service) Get(userID, limit, offset int) ([]follow.Suggestion, error) {
+	s.mu.RLock()
+	e, ok := s.cache[userID]
+	s.mu.RUnlock()
+
+	if !ok || time.Since(e.computedAt) > s.ttl {
+		items, err := s.compute(userID)
+		if err != nil {
+			return nil, err
+		}
+		e = s.store(userID, items)
+	}
+
+	excluded, err := s.source.ExcludedIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []follow.Suggestion{}
+	for _, item := range e.items {
+		if excluded[item.User.Id] {
+			continue
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		result = append(result, item)
+		if len(result) == limit {
+			break
+		}
+	}
+	return result, nil
+}
+
+// Evict drops the cached suggestions of userID and removes the user from
+// everyone else's, for accounts that are deactivated or deleted.
+func (s *Service) Evict(userID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.cache, userID)
+	for id, e := range s.cache {
+		for i, item := range e.items {
+			if item.User.Id != userID {
+				continue
+			}
+			items := make([]follow.Suggestion, 0, len(e.items)-1)
+			items = append(items, e.items[:i]...)
+			items = append(items, e.items[i+1:]...)
+			s.cache[id] = entry{items: items, computedAt: e.computedAt}
+			break
+		}
+	}
+}
+
+// Prune drops the entries older than the TTL.
+func (s *Service) Prune() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, e := range s.cache {
+		if time.Since(e.computedAt) > s.ttl {
+			delete(s.cache, id)
+		}
+	}
+	return nil
+}
+
+// compute ranks friends-of-friends by mutual count and fills the rest of the
+// pool with popular accounts for users with a small network.
+func (s *Service) compute(userID int) ([]follow.Suggestion, error) {
+	items, err := s.source.FriendsOfFriends(userID, poolSize)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) >= poolSize {
+		return items, nil
+	}
+
+	popular, err := s.source.Popular(userID, poolSize)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[int]bool{}
+	for _, item := range items {
+		seen[item.User.Id] = true
+	}
+	for _, item := range popular {
+		if len(items) >= poolSize {
+			break
+		}
+		if !seen[item.User.Id] {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func (s *Service) store(userID int, items []follow.Suggestion) entry {
+	e := entry{items: items, computedAt: time.Now()}
+	s.mu.Lock()
+	s.cache[userID] = e
+	s.mu.Unlock()
+	return e
+}
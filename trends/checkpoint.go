@@ -0,0 +1,70 @@
+package trends
+
+import "time"
+
+// Checkpoint is a serializable copy of a tracker's state.
+type Checkpoint struct {
+	At         time.Time
+	Buckets    []BucketData
+	Candidates map[string]time.Time
+}
+
+// BucketData is one time bucket with its sketch in binary form.
+type BucketData struct {
+	Start time.Time
+	Data  []byte
+}
+
+// Store persists checkpoints so trends survive restarts.
+type Store interface {
+	SaveCheckpoint(cp Checkpoint) error
+	// LoadCheckpoint returns the last checkpoint, or nil if there is none.
+	LoadCheckpoint() (*Checkpoint, error)
+}
+
+// Snapshot copies the non-empty buckets and the candidates.
+func (t *Tracker) Snapshot() Checkpoint {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	cp := Checkpoint{At: t.now(), Candidates: make(map[string]time.Time, len(t.candidates))}
+	for _, b := range t.buckets {
+		if b.sketch == nil || b.sketch.empty() {
+			continue
+		}
+		cp.Buckets = append(cp.Buckets, BucketData{Start: b.start, Data: b.sketch.marshal()})
+	}
+	for term, seen := range t.candidates {
+		cp.Candidates[term] = seen
+	}
+	return cp
+}
+
+// Restore loads a checkpoint into the tracker, dropping buckets that are too
+// old to matter anymore or too far ahead of the clock.
+func (t *Tracker) Restore(cp Checkpoint) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	for _, data := range cp.Buckets {
+		if !t.inRange(data.Start.Truncate(t.cfg.Bucket), now) {
+			continue
+		}
+		var sketch countMin
+		if err := sketch.unmarshal(data.Data); err != nil {
+			return err
+		}
+		b := t.bucketFor(data.Start)
+		b.sketch = &sketch
+	}
+	for term, seen := range cp.Candidates {
+		t.candidates[term] = seen
+	}
+	return nil
+}
+
+// Save writes a checkpoint of the tracker to store.
+func (t *Tracker) Save(store Store) error {
+	return store.SaveCheckpoint(t.Snapshot())
+}
@@ -0,0 +1,76 @@
+package trends
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+)
+
+const (
+	sketchWidth = 1024
+	sketchDepth = 4
+)
+
+// countMin is a count-min sketch: a fixed-size table that estimates how many
+// times each key was added, never underestimating.
+type countMin struct {
+	counts [sketchDepth][sketchWidth]uint32
+}
+
+func (s *countMin) add(key string, n uint32) {
+	h1, h2 := hashes(key)
+	for i := 0; i < sketchDepth; i++ {
+		s.counts[i][(h1+uint64(i)*h2)%sketchWidth] += n
+	}
+}
+
+func (s *countMin) estimate(key string) uint32 {
+	h1, h2 := hashes(key)
+	min := ^uint32(0)
+	for i := 0; i < sketchDepth; i++ {
+		if c := s.counts[i][(h1+uint64(i)*h2)%sketchWidth]; c < min {
+			min = c
+		}
+	}
+	return min
+}
+
+func (s *countMin) empty() bool {
+	for _, c := range s.counts[0] {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *countMin) marshal() []byte {
+	buf := make([]byte, 0, sketchDepth*sketchWidth*4)
+	for i := range s.counts {
+		for _, c := range s.counts[i] {
+			buf = binary.LittleEndian.AppendUint32(buf, c)
+		}
+	}
+	return buf
+}
+
+func (s *countMin) unmarshal(data []byte) error {
+	if len(data) != sketchDepth*sketchWidth*4 {
+		return errors.New("trends: invalid sketch size")
+	}
+	for i := range s.counts {
+		for j := range s.counts[i] {
+			s.counts[i][j] = binary.LittleEndian.Uint32(data)
+			data = data[4:]
+		}
+	}
+	return nil
+}
+
+// hashes derives the two hashes combined to index each row (double hashing).
+func hashes(key string) (uint64, uint64) {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	sum := h.Sum64()
+	return sum, sum>>33 | 1
+}
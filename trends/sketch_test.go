@@ -0,0 +1,70 @@
+package trends
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCountMinNeverUnderestimates(t *testing.T) {
+	var s countMin
+	want := map[string]uint32{}
+	for i := 0; i < 5000; i++ {
+		key := fmt.Sprintf("term%d", i%700)
+		s.add(key, 1)
+		want[key]++
+	}
+	for key, n := range want {
+		if got := s.estimate(key); got < n {
+			t.Errorf("estimate(%q) = %d, want at least %d", key, got, n)
+		}
+	}
+}
+
+func TestCountMinIsExactWithoutCollisions(t *testing.T) {
+	var s countMin
+	s.add("golang", 3)
+	s.add("rust", 1)
+	s.add("golang", 2)
+
+	if got := s.estimate("golang"); got != 5 {
+		t.Errorf("estimate(golang) = %d, want 5", got)
+	}
+	if got := s.estimate("rust"); got != 1 {
+		t.Errorf("estimate(rust) = %d, want 1", got)
+	}
+	if got := s.estimate("python"); got != 0 {
+		t.Errorf("estimate(python) = %d, want 0", got)
+	}
+}
+
+func TestCountMinEmpty(t *testing.T) {
+	var s countMin
+	if !s.empty() {
+		t.Error("new sketch is not empty")
+	}
+	s.add("golang", 1)
+	if s.empty() {
+		t.Error("sketch with a key is empty")
+	}
+}
+
+func TestCountMinMarshalRoundTrip(t *testing.T) {
+	var s countMin
+	s.add("golang", 7)
+	s.add("#go", 2)
+
+	var got countMin
+	if err := got.unmarshal(s.marshal()); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got != s {
+		t.Error("unmarshal(marshal(s)) differs from s")
+	}
+}
+
+func TestCountMinUnmarshalRejectsWrongSize(t *testing.T) {
+	var s countMin
+	if err := s.unmarshal(make([]byte, 10)); err == nil {
+		t.Error("unmarshal accepted a truncated sketch")
+	}
+}
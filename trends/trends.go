@@ -0,0 +1,299 @@
+package trends
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"ualabackend/links"
+	"ualabackend/search"
+)
+
+const (
+	KindHashtag = "hashtag"
+	KindTerm    = "term"
+)
+
+// Config sets the windows trends are computed over. Volume in the last
+// Window is compared with the volume expected from the Baseline before it.
+type Config struct {
+	Bucket   time.Duration
+	Window   time.Duration
+	Baseline time.Duration
+	// MinCount is the volume a term needs in the window to trend.
+	MinCount int
+	// MaxCandidates bounds how many distinct terms are tracked.
+	MaxCandidates int
+}
+
+var DefaultConfig = Config{
+	Bucket:        5 * time.Minute,
+	Window:        time.Hour,
+	Baseline:      24 * time.Hour,
+	MinCount:      3,
+	MaxCandidates: 5000,
+}
+
+// Trend is a hashtag or term whose volume is accelerating.
+type Trend struct {
+	Name     string  `json:"name" example:"#golang"`
+	Kind     string  `json:"kind" example:"hashtag"`
+	Count    int     `json:"count" example:"42"`
+	Expected float64 `json:"expected" example:"3.5"`
+	Score    float64 `json:"score" example:"20.6"`
+}
+
+type bucket struct {
+	start  time.Time
+	sketch *countMin
+}
+
+// Tracker counts terms per time bucket with a count-min sketch and ranks the
+// recently seen ones by how far their current volume exceeds their baseline.
+// It is updated incrementally as tweets are published.
+type Tracker struct {
+	cfg Config
+	now func() time.Time
+
+	mu         sync.Mutex
+	buckets    []bucket
+	candidates map[string]time.Time
+}
+
+// NewTracker returns an empty tracker. now is the clock used to compute
+// trends; nil means time.Now.
+func NewTracker(cfg Config, now func() time.Time) *Tracker {
+	if now == nil {
+		now = time.Now
+	}
+	// The ring holds every bucket counts reads, from the start of the
+	// baseline to the current bucket, plus the next one, which Record
+	// accepts to tolerate clock skew. With one slot less the oldest bucket
+	// and the newest would share a slot and overwrite each other.
+	n := int((cfg.Window+cfg.Baseline)/cfg.Bucket) + 2
+	return &Tracker{
+		cfg:        cfg,
+		now:        now,
+		buckets:    make([]bucket, n),
+		candidates: map[string]time.Time{},
+	}
+}
+
+// Record counts the hashtags and terms of a tweet published at at.
+func (t *Tracker) Record(text string, at time.Time) {
+	terms := Extract(text)
+	if len(terms) == 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	if !t.inRange(at.Truncate(t.cfg.Bucket), now) {
+		return
+	}
+
+	b := t.bucketFor(at)
+	for _, term := range terms {
+		b.sketch.add(term, 1)
+		if seen, ok := t.candidates[term]; !ok || at.After(seen) {
+			t.candidates[term] = at
+		}
+	}
+	if len(t.candidates) > t.cfg.MaxCandidates {
+		t.prune(now)
+	}
+}
+
+// Trends returns up to limit trends, the strongest first.
+func (t *Tracker) Trends(limit int) []Trend {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	windowStart := now.Add(-t.cfg.Window)
+	ratio := float64(t.cfg.Window) / float64(t.cfg.Baseline)
+
+	top := &trendHeap{}
+	for term, seen := range t.candidates {
+		if seen.Before(windowStart) {
+			continue
+		}
+		current, baseline := t.counts(term, now)
+		if current < t.cfg.MinCount {
+			continue
+		}
+		expected := float64(baseline) * ratio
+		score := (float64(current) - expected) / math.Sqrt(expected+1)
+		if score <= 0 {
+			continue
+		}
+
+		trend := Trend{Name: term, Kind: KindTerm, Count: current, Expected: expected, Score: score}
+		if strings.HasPrefix(term, "#") {
+			trend.Kind = KindHashtag
+		}
+		heap.Push(top, trend)
+		if top.Len() > limit {
+			heap.Pop(top)
+		}
+	}
+
+	result := make([]Trend, top.Len())
+	for i := len(result) - 1; i >= 0; i-- {
+		result[i] = heap.Pop(top).(Trend)
+	}
+	return result
+}
+
+// counts returns the estimated volume of term in the current window and in
+// the baseline before it.
+func (t *Tracker) counts(term string, now time.Time) (current, baseline int) {
+	windowStart := now.Add(-t.cfg.Window).Truncate(t.cfg.Bucket)
+	baselineStart := t.oldest(now)
+	for _, b := range t.buckets {
+		if b.sketch == nil || b.start.Before(baselineStart) || b.start.After(now) {
+			continue
+		}
+		n := int(b.sketch.estimate(term))
+		if b.start.Before(windowStart) {
+			baseline += n
+		} else {
+			current += n
+		}
+	}
+	return current, baseline
+}
+
+// oldest returns the start of the oldest bucket counts reads at now.
+func (t *Tracker) oldest(now time.Time) time.Time {
+	return now.Add(-t.cfg.Window).Truncate(t.cfg.Bucket).Add(-t.cfg.Baseline)
+}
+
+// inRange reports whether the bucket starting at start fits in the ring at
+// now: no older than the oldest bucket counted and no newer than the one
+// after the current bucket.
+func (t *Tracker) inRange(start, now time.Time) bool {
+	return !start.Before(t.oldest(now)) && !start.After(now.Add(t.cfg.Bucket))
+}
+
+// bucketFor returns the bucket at falls in, recycling the ring slot if it
+// still holds an older bucket.
+func (t *Tracker) bucketFor(at time.Time) *bucket {
+	start := at.Truncate(t.cfg.Bucket)
+	b := &t.buckets[int(start.UnixNano()/int64(t.cfg.Bucket))%len(t.buckets)]
+	if b.sketch == nil || !b.start.Equal(start) {
+		b.start = start
+		b.sketch = &countMin{}
+	}
+	return b
+}
+
+// pruneTarget is the share of MaxCandidates prune leaves, so that the next
+// prune is at least a tenth of MaxCandidates new terms away instead of one.
+const pruneTarget = 0.9
+
+// prune drops the candidates not seen during the current window and, if
+// still over the low-water mark, the ones with the lowest current volume
+// down to it.
+func (t *Tracker) prune(now time.Time) {
+	windowStart := now.Add(-t.cfg.Window)
+	for term, seen := range t.candidates {
+		if seen.Before(windowStart) {
+			delete(t.candidates, term)
+		}
+	}
+	keep := max(1, int(float64(t.cfg.MaxCandidates)*pruneTarget))
+	if len(t.candidates) <= keep {
+		return
+	}
+
+	type scored struct {
+		term  string
+		count int
+	}
+	all := make([]scored, 0, len(t.candidates))
+	for term := range t.candidates {
+		current, _ := t.counts(term, now)
+		all = append(all, scored{term, current})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].count > all[j].count })
+	for _, s := range all[keep:] {
+		delete(t.candidates, s.term)
+	}
+}
+
+// stopwords are frequent Spanish and English words that never make a
+// meaningful trend.
+var stopwords = map[string]bool{}
+
+func init() {
+	for _, w := range strings.Fields(`
+		que los las del por para con una uno unos unas como mas pero sus esta este esto
+		hay muy sin sobre tambien fue ser son era todo todos ya hoy asi cuando donde
+		the and for with this that you are was were have has not but all from they
+		will just your its our out http https www`) {
+		stopwords[w] = true
+	}
+}
+
+// Extract returns the distinct trend keys of a text: hashtags prefixed with
+// '#' and words of at least three letters that are not stopwords. URLs are
+// ignored.
+func Extract(text string) []string {
+	matches := links.Detect(text)
+	for i := len(matches) - 1; i >= 0; i-- {
+		text = text[:matches[i].Start] + " " + text[matches[i].End:]
+	}
+
+	seen := map[string]bool{}
+	var keys []string
+	for _, tag := range search.Hashtags(text) {
+		key := "#" + tag
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	tags := map[string]bool{}
+	for _, tag := range search.Hashtags(text) {
+		tags[tag] = true
+	}
+	for _, word := range search.Tokenize(text) {
+		if tags[word] || seen[word] || stopwords[word] || utf8.RuneCountInString(word) < 3 || isNumber(word) {
+			continue
+		}
+		seen[word] = true
+		keys = append(keys, word)
+	}
+	return keys
+}
+
+func isNumber(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// trendHeap is a min-heap on score used to keep the top k trends.
+type trendHeap []Trend
+
+func (h trendHeap) Len() int            { return len(h) }
+func (h trendHeap) Less(i, j int) bool  { return h[i].Score < h[j].Score }
+func (h trendHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *trendHeap) Push(x interface{}) { *h = append(*h, x.(Trend)) }
+func (h *trendHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
@@ -0,0 +1,129 @@
+package trends
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2024, 1, 2, 12, 0, 30, 0, time.UTC)
+
+// testConfig keeps the ring small: 5 minutes of window after 10 of baseline.
+var testConfig = Config{
+	Bucket:        time.Minute,
+	Window:        5 * time.Minute,
+	Baseline:      10 * time.Minute,
+	MinCount:      3,
+	MaxCandidates: 100,
+}
+
+func newTracker() *Tracker {
+	return NewTracker(testConfig, func() time.Time { return now })
+}
+
+func TestTrendsRanksAcceleratingTerms(t *testing.T) {
+	tr := newTracker()
+	for i := 0; i < 5; i++ {
+		tr.Record("#golang rocks", now.Add(-time.Minute))
+	}
+	for i := 0; i < 3; i++ {
+		tr.Record("rocks", now.Add(-8*time.Minute))
+	}
+	for i := 0; i < 2; i++ {
+		tr.Record("#rust", now)
+	}
+
+	got := tr.Trends(10)
+	if len(got) != 2 {
+		t.Fatalf("Trends = %+v, want #golang and rocks", got)
+	}
+	if got[0].Name != "#golang" || got[0].Kind != KindHashtag || got[0].Count != 5 {
+		t.Errorf("first trend = %+v, want #golang with 5 uses", got[0])
+	}
+	if got[1].Name != "rocks" || got[1].Kind != KindTerm || got[1].Expected != 1.5 {
+		t.Errorf("second trend = %+v, want rocks expecting 1.5 uses", got[1])
+	}
+}
+
+func TestTrendsLimit(t *testing.T) {
+	tr := newTracker()
+	for i := 0; i < 3; i++ {
+		tr.Record("golang rust python", now)
+	}
+	if got := tr.Trends(2); len(got) != 2 {
+		t.Errorf("Trends(2) returned %d trends", len(got))
+	}
+}
+
+func TestRecordIgnoresOutOfRange(t *testing.T) {
+	tr := newTracker()
+	tr.Record("golang", tr.oldest(now).Add(-time.Second))
+	tr.Record("golang", now.Add(testConfig.Bucket+time.Second))
+
+	if current, baseline := tr.counts("golang", now); current != 0 || baseline != 0 {
+		t.Errorf("counts = %d, %d, want 0, 0", current, baseline)
+	}
+}
+
+func TestOldestAndNextBucketsDoNotShareASlot(t *testing.T) {
+	tr := newTracker()
+	tr.Record("golang", tr.oldest(now))
+	tr.Record("golang", now.Add(testConfig.Bucket))
+
+	if _, baseline := tr.counts("golang", now); baseline != 1 {
+		t.Errorf("baseline = %d, want 1", baseline)
+	}
+	later := now.Add(testConfig.Bucket)
+	if current, _ := tr.counts("golang", later); current != 1 {
+		t.Errorf("current a bucket later = %d, want 1", current)
+	}
+}
+
+func TestSnapshotRestoreRoundTrip(t *testing.T) {
+	tr := newTracker()
+	for i := 0; i < 4; i++ {
+		tr.Record("#golang", now.Add(-2*time.Minute))
+	}
+	tr.Record("#golang", now.Add(-12*time.Minute))
+
+	restored := newTracker()
+	if err := restored.Restore(tr.Snapshot()); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if got, want := restored.Trends(10), tr.Trends(10); !reflect.DeepEqual(got, want) {
+		t.Errorf("restored Trends = %+v, want %+v", got, want)
+	}
+}
+
+func TestRestoreDropsBucketsOutOfRange(t *testing.T) {
+	old := NewTracker(testConfig, func() time.Time { return now.Add(-time.Hour) })
+	for i := 0; i < 3; i++ {
+		old.Record("golang", now.Add(-time.Hour))
+	}
+	cp := old.Snapshot()
+
+	tr := newTracker()
+	tr.Record("golang", now)
+	if err := tr.Restore(cp); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if current, baseline := tr.counts("golang", now); current != 1 || baseline != 0 {
+		t.Errorf("counts = %d, %d, want 1, 0", current, baseline)
+	}
+}
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Aprendiendo #Golang con golang", []string{"#golang", "aprendiendo"}}, // the tag is not counted again as a word
+		{"mira esto https://example.com/golang", []string{"mira"}},
+		{"que los de 2024 ok", nil},
+	}
+	for _, tt := range tests {
+		if got := Extract(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Extract(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}